@@ -2,30 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"time"
 
-	"github.com/rs/cors"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/apiresources"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
 )
 
 // This is the entry point for the HTTP server
 // Locally, this program will run using localhost:8080 and can be stopped by performing a Ctrl+C
 // In a more sophisticated design that scales, I would serve this in a Lambda function that gets invoked via APIGateway
+// Pass -db=file to keep games in -data-dir so they survive a restart, by default they only live in memory
 func main() {
 
+	dbType := flag.String("db", "memory", "where games are stored, either memory or file")
+	dataDir := flag.String("data-dir", "tictactoe-data", "the directory games are stored in when -db=file")
+	flag.Parse()
+
 	fmt.Println("TicTacToe HTTP server has been invoked locally")
 	fmt.Println("Press CTR+C to quit")
 
-	router := apiresources.GetRouter()
+	var db database.DB
+	switch *dbType {
+	case "memory":
+		db = database.New()
+	case "file":
+		fileClient, err := database.NewFileClient(*dataDir)
+		if err != nil {
+			log.Fatalf("Failed to open the file DB in %s: %v\n", *dataDir, err)
+		}
+		defer fileClient.Close()
+		db = fileClient
+		fmt.Printf("Storing games in %s\n", *dataDir)
+	default:
+		log.Fatalf("Unknown -db %s, expected memory or file\n", *dbType)
+	}
+
+	router := apiresources.GetRouter(db)
+
+	// flag the timed games whose player to move ran out of time
+	stopClocks := apiresources.StartClockScheduler(time.Second)
+	defer stopClocks()
 
 	srv := http.Server{
 		Addr:    ":" + strconv.Itoa(8080),
-		Handler: cors.Default().Handler(apiresources.CaselessMatcher(router)),
+		Handler: apiresources.CORS(apiresources.CaselessMatcher(router)),
 	}
 
 	// create a seperate go routine that listens for the user to shut down the server using Ctrl+C
@@ -12,6 +12,20 @@ type DB struct {
 	mock.Mock
 }
 
+// CompareAndSwapGame provides a mock function with given fields: game
+func (_m *DB) CompareAndSwapGame(game database.Game) error {
+	ret := _m.Called(game)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(database.Game) error); ok {
+		r0 = rf(game)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateNewGame provides a mock function with given fields: game
 func (_m *DB) CreateNewGame(game database.Game) (string, error) {
 	ret := _m.Called(game)
@@ -0,0 +1,107 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+/*
+	RetrieveAnalysis evaluates the current position of an IN_PROGRESS game for the player whose turn it is
+	Boards with few enough empty squares are solved exactly. Larger boards only report wins and losses
+	the engine can see a couple of moves ahead, every other square is UNKNOWN
+
+	GET /tictactoe/{game_id}/analysis
+
+	Example Response
+		{
+			"error": null,
+			"data": {
+				"player": "player1", # the player the analysis is for
+				"result": "DRAW", # WIN, DRAW, LOSS or UNKNOWN for the player with best play from both sides
+				"decided": true, # true when the result is certain
+				"bestMoves": [{"row": 1, "column": 1}],
+				"evaluations": [{"row": 0, "column": 0, "result": "DRAW", "distance": null}, {"row": 0, "column": 2, "result": "LOSS", "distance": 4}, ...]
+			}
+		}
+
+	distance is the number of moves, the evaluated one included, until the result is reached
+	Misere and notakto games are analyzed by their own rules. The rows of a notakto game count through its boards one after the other
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  404 NotFound
+*/
+func RetrieveAnalysis(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	vars := mux.Vars(r)
+	gameID, ok := vars["game_id"]
+	if !ok {
+		http.Error(w, "game_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "game_id not provided"
+		return
+	}
+
+	game, err := dbClient.GetGameWithID(gameID)
+	if err != nil {
+		fmt.Printf("Failed to find game with gameID %s. Err: %s\n", gameID, err.Error())
+		http.Error(w, err.Error(), http.StatusNotFound)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	if game.State != database.StateInProgress {
+		e := fmt.Errorf("Game %s is %s, only IN_PROGRESS games can be analyzed", gameID, game.State)
+		http.Error(w, e.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if game.Variant != "" && game.Variant != database.VariantClassic {
+		e := fmt.Errorf("Game %s is %s, the engine can only analyze single board games", gameID, game.Variant)
+		http.Error(w, e.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if len(game.Players) > playersPerGame {
+		e := fmt.Errorf("Game %s has %d players, the engine can only analyze games of two players", gameID, len(game.Players))
+		http.Error(w, e.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if game.OpeningStage != "" {
+		e := fmt.Errorf("The opening of game %s is not over, the engine only analyzes the moves after it", gameID)
+		http.Error(w, e.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	position := gamePosition(&game)
+	analysis, err := engine.Analyze(position)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"player":      game.Players[markSeat(&game, position.ToMove)],
+		"result":      analysis.Result,
+		"decided":     analysis.Decided,
+		"bestMoves":   analysis.BestMoves,
+		"evaluations": analysis.Evaluations,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
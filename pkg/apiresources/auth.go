@@ -0,0 +1,116 @@
+package apiresources
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+)
+
+/*
+	Every human seat of a game gets a secret token when the seat is handed out. Only a hash of the token is stored
+	on the game, the token itself is returned to the player once and never again. Requests acting for a seat must
+	carry the seat's token as a bearer token:
+
+		Authorization: Bearer {token}
+*/
+
+// seatTokenBytes is how much randomness goes into a token, 32 bytes can not be guessed
+const seatTokenBytes = 32
+
+// newSeatToken creates a random token for a seat, returning the token for the player and the hash to store on the game
+func newSeatToken() (string, string, error) {
+
+	b := make([]byte, seatTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(b)
+	return token, hashSeatToken(token), nil
+}
+
+// hashSeatToken is the only form of a token that is ever stored
+func hashSeatToken(token string) string {
+
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// bearerToken pulls the token out of the Authorization header, or returns an empty string
+func bearerToken(r *http.Request) string {
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+}
+
+// seatForToken returns the seat the request's bearer token belongs to
+// 401 Unauthorized when the token is missing or does not belong to any seat of the game
+func seatForToken(r *http.Request, game *database.Game) (int, *statusError) {
+
+	token := bearerToken(r)
+	if len(token) == 0 {
+		return -1, newStatusError(http.StatusUnauthorized, "A bearer token for a seat of this game is required")
+	}
+
+	hash := hashSeatToken(token)
+	for seat, stored := range game.TokenHashes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(stored)) == 1 {
+			return seat, nil
+		}
+	}
+
+	return -1, newStatusError(http.StatusUnauthorized, "The bearer token does not belong to any seat of this game")
+}
+
+// authorizeSeat makes sure the request carries the token of the seat it acts for
+// 401 Unauthorized for a missing or unknown token, 403 Forbidden for the token of another seat
+func authorizeSeat(r *http.Request, game *database.Game, seat int) *statusError {
+
+	tokenSeat, e := seatForToken(r, game)
+	if e != nil {
+		return e
+	}
+
+	if tokenSeat != seat {
+		return newStatusError(http.StatusForbidden, "The bearer token does not belong to player %d", seat)
+	}
+
+	return nil
+}
+
+// issueSeatTokens hands a token to every human seat of the game that does not have one yet
+// Returns the new tokens by seat, they must be passed on to the players as they are never stored
+func issueSeatTokens(game *database.Game) (map[int]string, error) {
+
+	if game.TokenHashes == nil {
+		game.TokenHashes = map[int]string{}
+	}
+
+	tokens := map[int]string{}
+	for seat := range game.Players {
+		if _, ok := game.Bots[seat]; ok {
+			continue
+		}
+		if _, ok := game.TokenHashes[seat]; ok {
+			continue
+		}
+
+		token, hash, err := newSeatToken()
+		if err != nil {
+			return nil, err
+		}
+		tokens[seat] = token
+		game.TokenHashes[seat] = hash
+	}
+
+	return tokens, nil
+}
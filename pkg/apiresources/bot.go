@@ -0,0 +1,86 @@
+package apiresources
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+// playBotMove lets the computer move if it holds the seat whose turn it is
+// Returns the bot's move number, or -1 when it was not the computer's turn
+func playBotMove(game *database.Game) (int, error) {
+
+	if game.State != database.StateInProgress || game.NextPlayerIdx == -1 {
+		return -1, nil
+	}
+
+	difficulty, ok := game.Bots[game.NextPlayerIdx]
+	if !ok {
+		return -1, nil
+	}
+
+	square, err := chooseBotMove(game, difficulty)
+	if err != nil {
+		return -1, err
+	}
+
+	return applyMove(square.Row, square.Column, game.NextPlayerIdx, game)
+}
+
+// chooseBotMove asks the engine for the computer's move at the requested difficulty
+func chooseBotMove(game *database.Game, difficulty database.BotDifficulty) (engine.Square, error) {
+
+	// each request gets its own source, a shared *rand.Rand is not safe across concurrent handlers
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	position := gamePosition(game)
+
+	switch difficulty {
+	case database.BotDifficultyRandom:
+		return engine.RandomMove(position, rng), nil
+	case database.BotDifficultyEasy:
+		return engine.EasyMove(position, rng)
+	default:
+		return engine.BestMove(position, rng)
+	}
+}
+
+// gamePosition builds the engine's view of the game for the player whose turn it is
+// Before the first move either player may start, so the position is seen from seat 0
+func gamePosition(game *database.Game) engine.Position {
+
+	toMove := game.NextPlayerIdx
+	if toMove == -1 {
+		toMove = 0
+	}
+
+	position := engine.Position{
+		Board:     game.GameBoard,
+		WinLength: game.WinLength,
+		ToMove:    playerMark(game, toMove),
+		Gravity:   game.Gravity,
+		Rules:     engineRules(game.RuleSet),
+		Boards:    game.Boards,
+	}
+
+	if len(game.Players) > playersPerGame {
+		position.TurnOrder = turnOrder(game, toMove)
+	}
+
+	return position
+}
+
+// engineRules translates the rule set of a game for the engine, games without one are standard
+func engineRules(ruleSet database.RuleSet) engine.RuleSet {
+
+	switch ruleSet {
+	case database.RuleSetMisere:
+		return engine.RulesMisere
+	case database.RuleSetNotakto:
+		return engine.RulesNotakto
+	}
+
+	return engine.RulesStandard
+}
@@ -0,0 +1,187 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+)
+
+/*
+	Timed games give every player a clock. Only the clock of the player whose turn it is runs, and a player whose
+	clock reaches zero loses on time, their flag fell. A timed game is always opened by player 0, whose clock starts
+	as soon as the game does
+	Flags are caught in two places: a move arriving too late is rejected, and the clock scheduler sweeps every game in
+	the background so a player who simply stops moving still loses
+*/
+
+// now is the clock every time control reads, tests replace it to move time along
+var now = time.Now
+
+// startClocks fills every player's clock and starts player 0's, for a timed game that is starting
+func startClocks(game *database.Game) {
+
+	if game.TimeControl == nil {
+		return
+	}
+
+	full := fullClock(game.TimeControl)
+	game.Clocks = map[int]int64{}
+	for seat := range game.Players {
+		game.Clocks[seat] = full
+	}
+
+	started := now()
+	game.NextPlayerIdx = 0
+	game.TurnStartedAt = &started
+}
+
+// fullClock is the milliseconds a player starts with
+func fullClock(timeControl *database.TimeControl) int64 {
+
+	if timeControl.MoveSeconds > 0 {
+		return int64(timeControl.MoveSeconds) * 1000
+	}
+
+	return int64(timeControl.InitialSeconds) * 1000
+}
+
+// pressClock stops the clock of playerID who just moved and starts the opponent's, unless the move ended the game
+func pressClock(game *database.Game, playerID int) {
+
+	if game.TimeControl == nil || game.TurnStartedAt == nil {
+		return
+	}
+
+	// the turn was already handed over, so the time spent is charged to playerID here rather than by remainingTime
+	pressed := now()
+	if game.TimeControl.MoveSeconds > 0 {
+		game.Clocks[playerID] = fullClock(game.TimeControl)
+	} else {
+		game.Clocks[playerID] -= pressed.Sub(*game.TurnStartedAt).Milliseconds()
+		game.Clocks[playerID] += int64(game.TimeControl.IncrementSeconds) * 1000
+	}
+
+	if game.State != database.StateInProgress {
+		game.TurnStartedAt = nil
+		return
+	}
+	game.TurnStartedAt = &pressed
+}
+
+// stopClock charges the time spent so far to the player whose clock is running, before the turn is taken away from them
+func stopClock(game *database.Game, at time.Time) {
+
+	if game.TimeControl == nil || game.TurnStartedAt == nil {
+		return
+	}
+
+	game.Clocks[game.NextPlayerIdx] = remainingTime(game, game.NextPlayerIdx, at)
+	game.TurnStartedAt = nil
+}
+
+// resumeClock starts the clock of the player whose turn it now is
+func resumeClock(game *database.Game, at time.Time) {
+
+	if game.TimeControl == nil {
+		return
+	}
+
+	game.TurnStartedAt = &at
+}
+
+// remainingTime returns the milliseconds playerID has left at the given time, never less than zero
+func remainingTime(game *database.Game, playerID int, at time.Time) int64 {
+
+	remaining := game.Clocks[playerID]
+	if game.TurnStartedAt != nil && game.NextPlayerIdx == playerID {
+		remaining -= at.Sub(*game.TurnStartedAt).Milliseconds()
+	}
+
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// flagFell returns true when the player whose turn it is ran out of time
+func flagFell(game *database.Game, at time.Time) bool {
+
+	if game.State != database.StateInProgress || game.TimeControl == nil || game.TurnStartedAt == nil {
+		return false
+	}
+
+	return remainingTime(game, game.NextPlayerIdx, at) == 0
+}
+
+// flagGame completes the game with the player whose turn it is losing on time
+func flagGame(game *database.Game) {
+
+	// validatePartyGame never times a game of more than two players, the next seat is the only other one
+	loser := game.NextPlayerIdx
+	winner := game.Players[nextSeat(game, loser)]
+
+	game.Clocks[loser] = 0
+	game.TurnStartedAt = nil
+	game.State = database.StateComplete
+	game.Winner = &winner
+	game.Forfeit = &database.Forfeit{
+		Player: game.Players[loser],
+		Reason: database.ForfeitReasonTimeout,
+	}
+}
+
+/*
+	StartClockScheduler sweeps all games every interval, completing the timed games whose player to move ran out of time
+	Returns a function that stops the scheduler
+*/
+func StartClockScheduler(interval time.Duration) func() {
+
+	done := make(chan bool)
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				checkClocks()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// checkClocks flags every game whose player to move ran out of time
+func checkClocks() {
+
+	games, err := dbClient.GetAllGames()
+	if err != nil {
+		fmt.Printf("Failed to retrieve games to check their clocks. Err: %s\n", err.Error())
+		return
+	}
+
+	for _, game := range games {
+		if !flagFell(&game, now()) {
+			continue
+		}
+
+		_, e := updateGame(game.ID, func(game *database.Game) *statusError {
+			// the player may have moved since the sweep read the game
+			if !flagFell(game, now()) {
+				return newStatusError(http.StatusConflict, "Game %s is no longer out of time", game.ID)
+			}
+
+			flagGame(game)
+			return nil
+		})
+		if e != nil && e.status != http.StatusConflict {
+			fmt.Printf("Failed to flag game %s. Err: %s\n", game.ID, e.Error())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// A test file for only clock.go
+
+func TestPressClockAddsIncrement(t *testing.T) {
+
+	start := time.Now()
+	defer setNow(start)()
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.TimeControl = &database.TimeControl{InitialSeconds: 60, IncrementSeconds: 2}
+	startClocks(&game)
+	assert.Equal(t, 0, game.NextPlayerIdx)
+
+	// player 0 thinks for 10 seconds
+	now = func() time.Time { return start.Add(10 * time.Second) }
+	_, err := applyMove(1, 1, 0, &game)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(52000), game.Clocks[0])
+	assert.Equal(t, int64(60000), remainingTime(&game, 1, start.Add(10*time.Second)))
+	assert.Equal(t, int64(55000), remainingTime(&game, 1, start.Add(15*time.Second)))
+	assert.False(t, flagFell(&game, start.Add(69*time.Second)))
+	assert.True(t, flagFell(&game, start.Add(70*time.Second)))
+}
+
+func TestPostAMoveOutOfTime(t *testing.T) {
+
+	start := time.Now()
+	defer setNow(start.Add(31 * time.Second))()
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game {
+		game := generateGameWithBoard(3, 3, 3)
+		game.TimeControl = &database.TimeControl{MoveSeconds: 30}
+		game.Clocks = map[int]int64{0: 30000, 1: 30000}
+		game.NextPlayerIdx = 0
+		game.TurnStartedAt = &start
+		return game
+	}, nil)
+
+	var stored database.Game
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player2", *stored.Winner)
+	assert.Equal(t, database.ForfeitReasonTimeout, stored.Forfeit.Reason)
+	assert.Empty(t, stored.Moves)
+}
+
+func TestCheckClocksFlagsStalledGame(t *testing.T) {
+
+	start := time.Now()
+	defer setNow(start.Add(time.Minute))()
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	stalled := func(string) database.Game {
+		game := generateGameWithBoard(3, 3, 3)
+		game.TimeControl = &database.TimeControl{InitialSeconds: 30}
+		game.Clocks = map[int]int64{0: 30000, 1: 30000}
+		game.NextPlayerIdx = 1
+		game.TurnStartedAt = &start
+		return game
+	}
+	untimed := generateGameWithBoard(3, 3, 3)
+	untimed.ID = "gameID2"
+
+	dbMock.On("GetAllGames").Return([]database.Game{stalled("gameID1"), untimed}, nil)
+	dbMock.On("GetGameWithID", "gameID1").Return(stalled, nil)
+
+	var stored database.Game
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	checkClocks()
+
+	dbMock.AssertNumberOfCalls(t, "CompareAndSwapGame", 1)
+	assert.Equal(t, "gameID1", stored.ID)
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+	assert.Equal(t, &database.Forfeit{Player: "player2", Reason: database.ForfeitReasonTimeout}, stored.Forfeit)
+}
+
+// setNow freezes the package clock at t, returning a function that restores it
+func setNow(t time.Time) func() {
+	now = func() time.Time { return t }
+	return func() { now = time.Now }
+}
@@ -0,0 +1,275 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
+)
+
+/*
+	OfferDraw offers the opponent to end the game as a draw
+	The offer stands until the opponent answers it or plays a move, which declines it.
+	The computer answers straight away, accepting unless it can see a win for itself
+
+	POST /tictactoe/{game_id}/{player_id}/draw
+
+	Example Response
+		{
+			"error": null,
+			"data": {"drawOfferedBy": 0, "accepted": false}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  401 Unauthorized
+	  403 Forbidden
+	  404 NotFound
+	  409 Conflict, the game is over, a player ran out of time, a draw offer already stands or the game was updated by another request
+	  500 InternalServerError
+*/
+func OfferDraw(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	accepted := false
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkDrawAllowed(game, playerID); e != nil {
+			return e
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		// a player who ran out of time already lost, the game can not be drawn any more
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		if game.DrawOfferedBy != nil {
+			return newStatusError(http.StatusConflict, "Player %d already offered a draw, it has to be answered first", *game.DrawOfferedBy)
+		}
+
+		opponent := opponentOf(playerID)
+		if _, ok := game.Bots[opponent]; ok {
+			accepted = botAcceptsDraw(game, opponent)
+			if accepted {
+				agreeDraw(game, opponent)
+			}
+			return nil
+		}
+
+		game.DrawOfferedBy = &playerID
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "%s ran out of time, %s wins the game", game.Forfeit.Player, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"drawOfferedBy": playerID,
+		"accepted":      accepted,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	AnswerDraw lets the opponent accept or decline the draw offered to them
+	Accepting records a DRAW move for the player accepting and completes the game without a winner
+
+	PUT /tictactoe/{game_id}/{player_id}/draw
+
+	Example Request
+		{
+			"accept": true
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {"drawOfferedBy": 0, "accepted": true}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  401 Unauthorized
+	  403 Forbidden, only the opponent of the player offering can answer
+	  404 NotFound, also when no draw was offered
+	  409 Conflict, the game is over, a player ran out of time or the game was updated by another request
+	  500 InternalServerError
+*/
+func AnswerDraw(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type AnswerRequest struct {
+		Accept *bool `json:"accept" validate:"required"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	answerRequest := AnswerRequest{}
+	err = json.Unmarshal(requestBody, &answerRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(answerRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	offeredBy := -1
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkDrawAllowed(game, playerID); e != nil {
+			return e
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		// a player who ran out of time already lost, the game can not be drawn any more
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		if game.DrawOfferedBy == nil {
+			return newStatusError(http.StatusNotFound, "No draw was offered in game %s", gameID)
+		}
+
+		if *game.DrawOfferedBy == playerID {
+			return newStatusError(http.StatusForbidden, "Player %d can not answer their own draw offer", playerID)
+		}
+
+		offeredBy = *game.DrawOfferedBy
+		game.DrawOfferedBy = nil
+		if *answerRequest.Accept {
+			agreeDraw(game, playerID)
+		}
+
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "%s ran out of time, %s wins the game", game.Forfeit.Player, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"drawOfferedBy": offeredBy,
+		"accepted":      *answerRequest.Accept,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkDrawAllowed rejects draw offers and answers in games that are over, and for players not in the game
+func checkDrawAllowed(game *database.Game, playerID int) *statusError {
+
+	if game.State != database.StateInProgress {
+		return newStatusError(http.StatusConflict, "Game %s is %s, it can not be drawn", game.ID, game.State)
+	}
+
+	if _, ok := game.Players[playerID]; !ok {
+		return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+	}
+
+	if len(game.Players) > playersPerGame {
+		return newStatusError(http.StatusBadRequest, "Draws can only be offered in a game of two players")
+	}
+
+	return nil
+}
+
+// agreeDraw records the DRAW move of the player accepting and completes the game without a winner
+func agreeDraw(game *database.Game, playerID int) {
+
+	game.Moves = append(game.Moves, database.Move{
+		Type:   database.MoveTypeDraw,
+		Player: game.Players[playerID],
+	})
+
+	game.DrawOfferedBy = nil
+	game.PendingTakeback = nil
+	game.State = database.StateComplete
+	game.Winner = nil
+	game.DrawReason = database.DrawReasonAgreed
+	stopClock(game, now())
+}
+
+// botAcceptsDraw returns false only when the engine is sure the computer at seat wins the game
+func botAcceptsDraw(game *database.Game, seat int) bool {
+
+	position := gamePosition(game)
+	analysis, err := engine.Analyze(position)
+	if err != nil || !analysis.Decided {
+		return true
+	}
+
+	if position.ToMove == seat {
+		return analysis.Result != engine.ResultWin
+	}
+
+	return analysis.Result != engine.ResultLoss
+}
@@ -0,0 +1,102 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only draw.go
+
+func TestDrawAgreed(t *testing.T) {
+
+	stored := generateGameWithBoard(15, 15, 5)
+	_, err := applyMove(7, 7, 0, &stored)
+	assert.NoError(t, err)
+
+	storeGame(&stored)
+
+	w := httptest.NewRecorder()
+	OfferDraw(w, newActionRequest(http.MethodPost, "0", "draw", ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, *stored.DrawOfferedBy)
+
+	// the player offering can not accept for the opponent
+	w = httptest.NewRecorder()
+	AnswerDraw(w, newActionRequest(http.MethodPut, "0", "draw", `{"accept": true}`))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	AnswerDraw(w, newActionRequest(http.MethodPut, "1", "draw", `{"accept": true}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Nil(t, stored.Winner)
+	assert.Nil(t, stored.DrawOfferedBy)
+	assert.Equal(t, database.Move{Type: database.MoveTypeDraw, Player: "player2"}, stored.Moves[1])
+}
+
+func TestDrawNotAgreedAfterFlagFell(t *testing.T) {
+
+	start := time.Now()
+	defer setNow(start.Add(31 * time.Second))()
+
+	// player 0 offered a draw, then player 1 let their clock run out before answering
+	stored := generateGameWithBoard(3, 3, 3)
+	offeredBy := 0
+	stored.DrawOfferedBy = &offeredBy
+	stored.TimeControl = &database.TimeControl{MoveSeconds: 30}
+	stored.Clocks = map[int]int64{0: 30000, 1: 30000}
+	stored.NextPlayerIdx = 1
+	stored.TurnStartedAt = &start
+	storeGame(&stored)
+
+	w := httptest.NewRecorder()
+	AnswerDraw(w, newActionRequest(http.MethodPut, "1", "draw", `{"accept": true}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+	assert.Equal(t, &database.Forfeit{Player: "player2", Reason: database.ForfeitReasonTimeout}, stored.Forfeit)
+	assert.Empty(t, stored.DrawReason)
+	assert.Empty(t, stored.Moves)
+}
+
+func TestDrawOfferDeclinedByMove(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	offeredBy := 0
+	game.DrawOfferedBy = &offeredBy
+
+	// the player offering keeps the offer standing when they move
+	_, err := applyMove(1, 1, 0, &game)
+	assert.NoError(t, err)
+	assert.NotNil(t, game.DrawOfferedBy)
+
+	// the opponent moving on declines it
+	_, err = applyMove(0, 0, 1, &game)
+	assert.NoError(t, err)
+	assert.Nil(t, game.DrawOfferedBy)
+}
+
+func TestBotAcceptsDraw(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.Bots = map[int]database.BotDifficulty{1: database.BotDifficultyPerfect}
+
+	// an empty board is a draw with best play
+	game.NextPlayerIdx = 0
+	assert.True(t, botAcceptsDraw(&game, 1))
+
+	// the computer threatens to win in two places and player 0 can only block one
+	game.GameBoard = [][]int{
+		{1, 1, -1},
+		{1, 0, 0},
+		{-1, 0, -1},
+	}
+	assert.False(t, botAcceptsDraw(&game, 1))
+}
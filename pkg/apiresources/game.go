@@ -5,13 +5,21 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	uuid "github.com/satori/go.uuid"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
 )
 
+// playersPerGame is the number of seats in every game, unless it is created with more players up to maxPlayersPerGame
+const playersPerGame = 2
+
+// maxPlayersPerGame is the most players a party game can seat, each with their own mark
+const maxPlayersPerGame = 4
+
 /*
 	RetrieveAllGames retrieves all games from the DB that are of state IN_PROGRESS
 
@@ -55,6 +63,7 @@ func RetrieveAllGames(w http.ResponseWriter, r *http.Request) {
 
 /*
 	CreateNewGame creates a new game in the DB
+	Every other field, the variant, rules, computer player and time control, is optional, see the README
 
 	Request Body
 	{
@@ -66,7 +75,10 @@ func RetrieveAllGames(w http.ResponseWriter, r *http.Request) {
 	Response
 		{
 			"error": null,
-			"data": {"gameId": "gameUUID"}
+			"data": {
+				"gameId": "gameUUID",
+				"tokens": {"0": "token for player1", "1": "token for player2"} # each token is only ever returned here
+			}
 		}
 
 	StatusCodes
@@ -79,10 +91,32 @@ func CreateNewGame(w http.ResponseWriter, r *http.Request) {
 	response := Response{ErrorMessage: new(string)}
 	defer json.NewEncoder(w).Encode(&response)
 
+	type BotRequest struct {
+		Seat       *int   `json:"seat" validate:"required,gte=0,lte=1"`
+		Difficulty string `json:"difficulty" validate:"required,oneof=random easy perfect"`
+	}
+
+	type TimeControlRequest struct {
+		InitialSeconds   *int `json:"initialSeconds" validate:"omitempty,gte=1"`
+		IncrementSeconds *int `json:"incrementSeconds" validate:"omitempty,gte=0"`
+		MoveSeconds      *int `json:"moveSeconds" validate:"omitempty,gte=1"`
+	}
+
 	type GameRequest struct {
-		Players []string `json:"players" validate:"required,len=2"`
-		Columns *int     `json:"columns" validate:"required,eq=3"`
-		Rows    *int     `json:"rows" validate:"required,eq=3"`
+		Players     []string            `json:"players" validate:"required,min=1,max=4"`
+		Columns     *int                `json:"columns" validate:"required,gte=3,lte=25"`
+		Rows        *int                `json:"rows" validate:"required,gte=3,lte=25"`
+		WinLength   *int                `json:"winLength" validate:"omitempty,gte=3"`
+		Bot         *BotRequest         `json:"bot"`
+		TimeControl *TimeControlRequest `json:"timeControl"`
+		Rated       bool                `json:"rated"`
+		Gravity     bool                `json:"gravity"`
+		Variant     string              `json:"variant" validate:"omitempty,oneof=classic ultimate qubic wild order-chaos quantum numerical"`
+		RuleSet     string              `json:"ruleSet" validate:"omitempty,oneof=standard misere notakto"`
+		Boards      *int                `json:"boards" validate:"omitempty,gte=1,lte=9"`
+		OrderSeat   *int                `json:"orderSeat" validate:"omitempty,gte=0,lte=1"`
+		Opening     string              `json:"opening" validate:"omitempty,oneof=pro swap swap2"`
+		PieRule     bool                `json:"pieRule"`
 	}
 
 	v := validator.New()
@@ -109,25 +143,162 @@ func CreateNewGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newBoard := [][]int{}
-	for i := 0; i < *gameRequest.Rows; i++ {
-		row := []int{}
-		for j := 0; j < *gameRequest.Columns; j++ {
-			row = append(row, -1)
+	variant := database.VariantClassic
+	if len(gameRequest.Variant) > 0 {
+		variant = database.Variant(gameRequest.Variant)
+	}
+
+	ok, errMsg := validateVariant(variant, *gameRequest.Rows, *gameRequest.Columns, gameRequest.WinLength, gameRequest.Gravity, gameRequest.Bot != nil)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	if gameRequest.OrderSeat != nil && variant != database.VariantOrderAndChaos {
+		http.Error(w, "orderSeat is only for order-chaos games", http.StatusBadRequest)
+		*response.ErrorMessage = "orderSeat is only for order-chaos games"
+		return
+	}
+
+	ruleSet := database.RuleSetStandard
+	if len(gameRequest.RuleSet) > 0 {
+		ruleSet = database.RuleSet(gameRequest.RuleSet)
+	}
+
+	ok, errMsg = validateRuleSet(ruleSet, variant, gameRequest.Boards, gameRequest.Gravity)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	ok, errMsg = validatePartyGame(len(gameRequest.Players), *gameRequest.Rows, *gameRequest.Columns, variant, ruleSet, gameRequest.Bot != nil, gameRequest.TimeControl != nil)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	winLength := defaultWinLength(variant, *gameRequest.Rows, *gameRequest.Columns, gameRequest.WinLength)
+	ok, errMsg = validateWinLength(winLength, *gameRequest.Rows, *gameRequest.Columns)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	opening := database.OpeningRule(gameRequest.Opening)
+	ok, errMsg = validateOpening(opening, variant, ruleSet, len(gameRequest.Players), *gameRequest.Rows, *gameRequest.Columns, winLength, gameRequest.Gravity, gameRequest.Bot != nil)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	ok, errMsg = validatePieRule(gameRequest.PieRule, variant, ruleSet, opening, len(gameRequest.Players), gameRequest.Bot != nil)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	if gameRequest.Bot != nil && len(gameRequest.Players) < playersPerGame {
+		http.Error(w, "A game against the computer can not have an open seat", http.StatusBadRequest)
+		*response.ErrorMessage = "A game against the computer can not have an open seat"
+		return
+	}
+
+	var timeControl *database.TimeControl
+	if gameRequest.TimeControl != nil {
+		tc := gameRequest.TimeControl
+		if (tc.InitialSeconds == nil) == (tc.MoveSeconds == nil) {
+			http.Error(w, "timeControl needs exactly one of initialSeconds or moveSeconds", http.StatusBadRequest)
+			*response.ErrorMessage = "timeControl needs exactly one of initialSeconds or moveSeconds"
+			return
+		}
+		if tc.IncrementSeconds != nil && tc.InitialSeconds == nil {
+			http.Error(w, "incrementSeconds can only be added to initialSeconds", http.StatusBadRequest)
+			*response.ErrorMessage = "incrementSeconds can only be added to initialSeconds"
+			return
+		}
+
+		timeControl = &database.TimeControl{}
+		if tc.InitialSeconds != nil {
+			timeControl.InitialSeconds = *tc.InitialSeconds
+		}
+		if tc.IncrementSeconds != nil {
+			timeControl.IncrementSeconds = *tc.IncrementSeconds
+		}
+		if tc.MoveSeconds != nil {
+			timeControl.MoveSeconds = *tc.MoveSeconds
 		}
-		newBoard = append(newBoard, row)
 	}
 
-	game := database.Game{
-		ID:            uuid.NewV4().String(),
-		Players:       map[int]string{0: gameRequest.Players[0], 1: gameRequest.Players[1]},
-		Columns:       *gameRequest.Columns,
-		Rows:          *gameRequest.Rows,
-		State:         database.StateInProgress,
-		Moves:         []database.Move{},
-		Winner:        nil,
-		NextPlayerIdx: -1,
-		GameBoard:     newBoard,
+	game := newGame(gameRequest.Players, *gameRequest.Rows, *gameRequest.Columns, winLength)
+	game.TimeControl = timeControl
+	game.Rated = gameRequest.Rated
+	game.Gravity = gameRequest.Gravity
+	game.Variant = variant
+	if variant == database.VariantQubic {
+		game.Layers = engine.QubicSide
+		game.GameBoard = emptyBoard(game.Layers*game.Rows, game.Columns)
+	}
+	if variant == database.VariantOrderAndChaos {
+		order := 0
+		if gameRequest.OrderSeat != nil {
+			order = *gameRequest.OrderSeat
+		}
+		game.Roles = orderAndChaosRoles(order)
+	}
+	if variant == database.VariantUltimate {
+		game.Ultimate = newUltimate()
+		game.GameBoard = [][]int{}
+	}
+	if variant == database.VariantQuantum {
+		game.Quantum = &database.Quantum{Marks: []database.SpookyMark{}}
+	}
+	if variant == database.VariantNumerical {
+		game.Numbers = numericalNumbers()
+	}
+	game.Opening = opening
+	switch opening {
+	case database.OpeningPro:
+		game.Colors = map[int]database.Color{0: database.ColorBlack, 1: database.ColorWhite}
+	case database.OpeningSwap, database.OpeningSwap2:
+		game.OpeningStage = database.OpeningStagePlace
+	}
+	game.PieRule = gameRequest.PieRule
+	game.RuleSet = ruleSet
+	if ruleSet == database.RuleSetNotakto {
+		game.Boards = 1
+		if gameRequest.Boards != nil {
+			game.Boards = *gameRequest.Boards
+		}
+		game.GameBoard = emptyBoard(game.Boards*game.Rows, game.Columns)
+	}
+
+	if gameRequest.Bot != nil {
+		game.Bots = map[int]database.BotDifficulty{*gameRequest.Bot.Seat: database.BotDifficulty(gameRequest.Bot.Difficulty)}
+	}
+
+	// hand every human seat its secret token, only the hash is stored
+	tokens, err := issueSeatTokens(&game)
+	if err != nil {
+		fmt.Printf("Failed to create seat tokens: %s", err.Error())
+		http.Error(w, "InternalServerError handling creation of new game", http.StatusInternalServerError)
+		*response.ErrorMessage = "InternalServerError handling creation of new game"
+		return
+	}
+
+	// a game with an open seat waits in the lobby until somebody joins
+	if len(game.Players) >= playersPerGame {
+		if err := startGame(&game); err != nil {
+			fmt.Printf("Failed to start the game: %s", err.Error())
+			http.Error(w, "InternalServerError handling creation of new game", http.StatusInternalServerError)
+			*response.ErrorMessage = "InternalServerError handling creation of new game"
+			return
+		}
 	}
 
 	id, err := dbClient.CreateNewGame(game)
@@ -140,20 +311,261 @@ func CreateNewGame(w http.ResponseWriter, r *http.Request) {
 
 	response.Data = map[string]interface{}{
 		"gameId": id,
+		"tokens": tokens,
 	}
 	response.ErrorMessage = nil
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// newGame builds an empty rows x columns game waiting for its players. Seats are handed out in the order of players
+func newGame(players []string, rows, columns, winLength int) database.Game {
+
+	seats := map[int]string{}
+	for i, player := range players {
+		seats[i] = player
+	}
+
+	return database.Game{
+		ID:            uuid.NewV4().String(),
+		Players:       seats,
+		Columns:       columns,
+		Rows:          rows,
+		WinLength:     winLength,
+		State:         database.StateWaitingForPlayers,
+		Moves:         []database.Move{},
+		Winner:        nil,
+		NextPlayerIdx: -1,
+		GameBoard:     emptyBoard(rows, columns),
+		Variant:       database.VariantClassic,
+	}
+}
+
+// emptyBoard returns a rows x columns board without a single square taken
+func emptyBoard(rows, columns int) [][]int {
+
+	newBoard := [][]int{}
+	for i := 0; i < rows; i++ {
+		row := []int{}
+		for j := 0; j < columns; j++ {
+			row = append(row, -1)
+		}
+		newBoard = append(newBoard, row)
+	}
+
+	return newBoard
+}
+
+// startGame puts a game whose seats are all taken into play, letting the computer open when it holds seat 0
+func startGame(game *database.Game) error {
+
+	game.State = database.StateInProgress
+	startClocks(game)
+	game.NextPlayerIdx = openingSeat(game)
+
+	if _, ok := game.Bots[0]; ok {
+		if _, err := playBotMove(game); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openingSeat returns the seat making the first move of a game, or -1 when either player may
+// The computer opens when it holds seat 0, Order always opens an order-chaos game,
+// and player 0 a timed game, a game with an opening or a numerical game
+func openingSeat(game *database.Game) int {
+
+	if _, ok := game.Bots[0]; ok {
+		return 0
+	}
+
+	if order, ok := orderSeat(game); ok {
+		return order
+	}
+
+	// the odd numbers open, there is one more of them than there are even numbers
+	if game.TimeControl != nil || game.Opening != "" || game.Variant == database.VariantNumerical {
+		return 0
+	}
+
+	return -1
+}
+
+// defaultWinLength returns the requested win length, or the shorter side of the board so a 3x3 board plays classic TicTacToe
+// Ultimate always wins with a line across a single small board, Qubic with a line through the cube, Order with five in a row
+// and Quantum with three classical marks in a row
+func defaultWinLength(variant database.Variant, rows, columns int, requested *int) int {
+
+	if requested != nil {
+		return *requested
+	}
+
+	switch variant {
+	case database.VariantUltimate:
+		return engine.UltimateSide
+	case database.VariantQubic:
+		return engine.QubicSide
+	case database.VariantOrderAndChaos:
+		return orderAndChaosWinLength
+	case database.VariantQuantum:
+		return engine.QuantumSide
+	}
+
+	if columns < rows {
+		return columns
+	}
+
+	return rows
+}
+
+// validateVariant makes sure the settings of a new game fit the rules of its variant
+func validateVariant(variant database.Variant, rows, columns int, winLength *int, gravity, bot bool) (bool, string) {
+
+	switch variant {
+	case database.VariantUltimate:
+		side := engine.UltimateSide * engine.UltimateSide
+		if rows != side || columns != side {
+			return false, fmt.Sprintf("ultimate is played on %d boards of %dx%d, so it needs %d rows and %d columns", side, engine.UltimateSide, engine.UltimateSide, side, side)
+		}
+		if winLength != nil && *winLength != engine.UltimateSide {
+			return false, fmt.Sprintf("ultimate boards are won with %d in a row", engine.UltimateSide)
+		}
+		if gravity {
+			return false, "ultimate can not be played with gravity"
+		}
+		if bot {
+			return false, "the computer does not play ultimate"
+		}
+	case database.VariantQubic:
+		if rows != engine.QubicSide || columns != engine.QubicSide {
+			return false, fmt.Sprintf("qubic is played in a %dx%dx%d cube, so it needs %d rows and %d columns", engine.QubicSide, engine.QubicSide, engine.QubicSide, engine.QubicSide, engine.QubicSide)
+		}
+		if winLength != nil && *winLength != engine.QubicSide {
+			return false, fmt.Sprintf("qubic is won with %d in a row", engine.QubicSide)
+		}
+		if gravity {
+			return false, "qubic can not be played with gravity"
+		}
+		if bot {
+			return false, "the computer does not play qubic"
+		}
+	case database.VariantWild:
+		if bot {
+			return false, "the computer does not play wild"
+		}
+	case database.VariantOrderAndChaos:
+		if rows != orderAndChaosSide || columns != orderAndChaosSide {
+			return false, fmt.Sprintf("order-chaos is played on a %dx%d board", orderAndChaosSide, orderAndChaosSide)
+		}
+		if winLength != nil && *winLength != orderAndChaosWinLength {
+			return false, fmt.Sprintf("order-chaos is won with %d in a row", orderAndChaosWinLength)
+		}
+		if gravity {
+			return false, "order-chaos can not be played with gravity"
+		}
+		if bot {
+			return false, "the computer does not play order-chaos"
+		}
+	case database.VariantQuantum:
+		if rows != engine.QuantumSide || columns != engine.QuantumSide {
+			return false, fmt.Sprintf("quantum is played on a %dx%d board", engine.QuantumSide, engine.QuantumSide)
+		}
+		if winLength != nil && *winLength != engine.QuantumSide {
+			return false, fmt.Sprintf("quantum is won with %d in a row", engine.QuantumSide)
+		}
+		if gravity {
+			return false, "quantum can not be played with gravity"
+		}
+		if bot {
+			return false, "the computer does not play quantum"
+		}
+	case database.VariantNumerical:
+		if rows != engine.NumericalSide || columns != engine.NumericalSide {
+			return false, fmt.Sprintf("numerical is played on a %dx%d board", engine.NumericalSide, engine.NumericalSide)
+		}
+		if winLength != nil && *winLength != engine.NumericalSide {
+			return false, fmt.Sprintf("numerical is won with a line of %d numbers", engine.NumericalSide)
+		}
+		if gravity {
+			return false, "numerical can not be played with gravity"
+		}
+		if bot {
+			return false, "the computer does not play numerical"
+		}
+	}
+
+	return true, ""
+}
+
+// validateRuleSet makes sure the rule set can be played with the variant and the settings of a new game
+func validateRuleSet(ruleSet database.RuleSet, variant database.Variant, boards *int, gravity bool) (bool, string) {
+
+	if ruleSet != database.RuleSetStandard && variant != database.VariantClassic {
+		return false, fmt.Sprintf("%s is only played on classic boards", ruleSet)
+	}
+
+	if ruleSet == database.RuleSetNotakto {
+		if gravity {
+			return false, "notakto can not be played with gravity"
+		}
+	} else if boards != nil {
+		return false, "only notakto is played on more than one board"
+	}
+
+	return true, ""
+}
+
+// validatePartyGame makes sure a game of more than two players is set up the way party games are played
+func validatePartyGame(players, rows, columns int, variant database.Variant, ruleSet database.RuleSet, bot, timed bool) (bool, string) {
+
+	if players <= playersPerGame {
+		return true, ""
+	}
+
+	if rows <= players || columns <= players {
+		return false, fmt.Sprintf("a game of %d players needs more than %d rows and columns", players, players)
+	}
+	if variant != database.VariantClassic || ruleSet != database.RuleSetStandard {
+		return false, "a game of more than two players is played on a classic board with the standard rules"
+	}
+	if bot {
+		return false, "the computer only plays games of two players"
+	}
+	if timed {
+		return false, "a game of more than two players can not be timed"
+	}
+
+	return true, ""
+}
+
+// validateWinLength makes sure a line of winLength squares fits on a rows x columns board
+func validateWinLength(winLength, rows, columns int) (bool, string) {
+
+	longestSide := rows
+	if columns > longestSide {
+		longestSide = columns
+	}
+
+	if winLength > longestSide {
+		return false, fmt.Sprintf("winLength %d does not fit on a %dx%d board, it must be at most %d", winLength, rows, columns, longestSide)
+	}
+
+	return true, ""
+}
+
 /*
 	RetrieveGameState retrieves the status of a game provided the gameID
+	Variants, openings and timed games add their own keys, see the README
 
 	Example Response
 	{
 		"error": null,
-		"data":	{ "players" : ["player1", "player2"], # The list of players.
-  		  		  "state": "COMPLETE/IN_PROGRESS",
+		"data":	{ "players" : ["player1", "player2"], # The list of players in seat order, an open seat is an empty name
+  		  		  "variant": "classic",
+  		  		  "ruleSet": "standard",
+  		  		  "state": "COMPLETE/IN_PROGRESS/QUIT",
            		   "winner": "player1", # IF draw, winner will be null, state will be COMPLETE.
                                 # IF in progess, key should not exist.
         		}
@@ -183,12 +595,33 @@ func RetrieveGameState(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	variant := game.Variant
+	if len(variant) == 0 {
+		variant = database.VariantClassic
+	}
+
+	ruleSet := game.RuleSet
+	if len(ruleSet) == 0 {
+		ruleSet = database.RuleSetStandard
+	}
+
+	seats := playersPerGame
+	if len(game.Players) > seats {
+		seats = len(game.Players)
+	}
+	players := []string{}
+	for seat := 0; seat < seats; seat++ {
+		players = append(players, game.Players[seat])
+	}
+
 	response.Data = map[string]interface{}{
-		"players": []string{game.Players[0], game.Players[1]},
+		"players": players,
 		"state":   string(game.State),
+		"variant": variant,
+		"ruleSet": ruleSet,
 	}
 
-	if game.State == database.StateComplete {
+	if game.State == database.StateComplete || game.State == database.StateQuit {
 		// If there is a draw, the winner is nil
 		if game.Winner == nil {
 			response.Data["winner"] = nil
@@ -197,23 +630,85 @@ func RetrieveGameState(w http.ResponseWriter, r *http.Request) {
 			response.Data["winner"] = game.Winner
 		}
 	}
+	if game.DrawReason != "" {
+		response.Data["drawReason"] = game.DrawReason
+	}
+	if game.Ultimate != nil {
+		response.Data["boards"] = game.Ultimate.Boards
+		response.Data["metaBoard"] = game.Ultimate.MetaBoard
+		if game.State == database.StateInProgress {
+			response.Data["activeBoard"] = game.Ultimate.ActiveBoard
+		}
+	}
+	if game.Forfeit != nil {
+		response.Data["forfeit"] = game.Forfeit
+	}
+	if game.PendingTakeback != nil {
+		response.Data["takeback"] = game.PendingTakeback
+	}
+	if game.DrawOfferedBy != nil {
+		response.Data["drawOfferedBy"] = *game.DrawOfferedBy
+	}
+	if len(game.Roles) > 0 {
+		response.Data["roles"] = game.Roles
+	}
+	if len(game.QuitPlayers) > 0 {
+		response.Data["quitPlayers"] = game.QuitPlayers
+	}
+	if game.Opening != "" {
+		response.Data["opening"] = game.Opening
+	}
+	if game.OpeningStage != "" {
+		response.Data["openingStage"] = game.OpeningStage
+	}
+	if len(game.Colors) > 0 {
+		response.Data["colors"] = game.Colors
+	}
+	if game.PieRule {
+		response.Data["pieRule"] = true
+	}
+	if game.Numbers != nil {
+		response.Data["numbers"] = game.Numbers
+	}
+	if game.Quantum != nil {
+		response.Data["quantum"] = game.Quantum
+	}
+	if game.TimeControl != nil {
+		clocks := map[int]int64{}
+		for seat := range game.Clocks {
+			clocks[seat] = remainingTime(&game, seat, now())
+		}
+		response.Data["timeControl"] = game.TimeControl
+		response.Data["clocks"] = clocks
+	}
 	response.ErrorMessage = nil
 
 	w.WriteHeader(http.StatusOK)
 }
 
 /*
-	QuitGame quits a game by updating a game with the state of QUIT given a gameID
+	QuitGame lets a player give up a game, given the game_id and the player_id of the seat quitting
+	A QUIT move is appended for the player and the game ends in the QUIT state. When at least one move had been played
+	the opponent is awarded the win, a game quit before its first move has no winner
+	A party game goes on without the player while at least two players are left, skipping their turns from then on
+	The request must carry the bearer token of the seat quitting
+
+	PUT /tictactoe/{game_id}/{player_id}/quit
 
 	Example Response
 		{
 			"error": null,
-			"quitGame": "gameID1"
+			"data": {"quitGame": "gameID1", "winner": "player2"} // winner omitempty
 		}
 
 	StatusCodes
 	  200 Ok
+	  400 BadRequest
+	  401 Unauthorized
+	  403 Forbidden
 	  404 NotFound
+	  409 Conflict, the game is already over, the player already quit or the game was updated by another request
+	  500 InternalServerError
 */
 func QuitGame(w http.ResponseWriter, r *http.Request) {
 
@@ -228,22 +723,106 @@ func QuitGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	game, err := dbClient.GetGameWithID(gameID)
+	playerIDStr, ok := vars["player_id"]
+	if !ok {
+		http.Error(w, "player_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "player_id not provided"
+		return
+	}
+	playerID, err := strconv.Atoi(playerIDStr)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		*response.ErrorMessage = err.Error()
+		http.Error(w, "player_id must be an integer", http.StatusBadRequest)
+		*response.ErrorMessage = "player_id must be an integer"
 		return
 	}
 
-	// update the game to have a QUIT state
-	game.State = database.StateQuit
-	dbClient.UpdateGame(game)
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateInProgress && game.State != database.StateWaitingForPlayers {
+			return newStatusError(http.StatusConflict, "Game %s is already over, it is %s", gameID, game.State)
+		}
+
+		player, ok := game.Players[playerID]
+		if !ok {
+			return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		if hasQuit(game, playerID) {
+			return newStatusError(http.StatusConflict, "Player %d already quit game %s", playerID, gameID)
+		}
+
+		forfeitGame(game, playerID, database.ForfeitReasonQuit)
+		game.Moves = append(game.Moves, database.Move{
+			Type:   database.MoveTypeQuit,
+			Player: player,
+		})
+
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
 
 	// let the UI handle the messaging
 	response.Data = map[string]interface{}{
 		"quitGame": gameID,
 	}
+	if game.Winner != nil {
+		response.Data["winner"] = *game.Winner
+	}
 	response.ErrorMessage = nil
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// forfeitGame ends the game with playerID giving it up, the opponent wins if the game had seen a move
+// A party game goes on without playerID as long as at least two players are left
+func forfeitGame(game *database.Game, playerID int, reason database.ForfeitReason) {
+
+	if len(activeSeats(game)) > playersPerGame {
+		game.QuitPlayers = append(game.QuitPlayers, playerID)
+		if game.NextPlayerIdx == playerID {
+			game.NextPlayerIdx = nextSeat(game, playerID)
+		}
+
+		// the lines only the quitting player could complete are gone with them
+		if !gamePosition(game).CanStillBeWon() {
+			game.State = database.StateComplete
+			game.DrawReason = database.DrawReasonNoWinnableLine
+		}
+		return
+	}
+
+	game.State = database.StateQuit
+	game.TurnStartedAt = nil
+	game.Forfeit = &database.Forfeit{
+		Player: game.Players[playerID],
+		Reason: reason,
+	}
+
+	played := false
+	for _, move := range game.Moves {
+		if move.Type == database.MoveTypeMove {
+			played = true
+			break
+		}
+	}
+
+	if !played {
+		return
+	}
+
+	// the last player left wins, a game still waiting in the lobby has nobody left
+	for _, seat := range activeSeats(game) {
+		if seat != playerID {
+			winner := game.Players[seat]
+			game.Winner = &winner
+		}
+	}
+}
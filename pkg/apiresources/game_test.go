@@ -12,6 +12,7 @@ import (
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // A test file for only game.go
@@ -106,6 +107,80 @@ func TestRetrieveGameStateFailure(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestQuitGameForfeitsToOpponent(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	// player1 already played a move
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game {
+		game := generateGameWithBoard(3, 3, 3)
+		game.GameBoard[1][1] = 0
+		game.Moves = []database.Move{{Type: database.MoveTypeMove, Player: "player1", Row: 1, Col: 1}}
+		game.NextPlayerIdx = 1
+		return game
+	}, nil)
+
+	var stored database.Game
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "1"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateQuit, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+	assert.Equal(t, &database.Forfeit{Player: "player2", Reason: database.ForfeitReasonQuit}, stored.Forfeit)
+	assert.Equal(t, database.Move{Type: database.MoveTypeQuit, Player: "player2"}, stored.Moves[1])
+}
+
+func TestQuitGameBeforeFirstMove(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game { return generateGameWithBoard(3, 3, 3) }, nil)
+
+	var stored database.Game
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	// quitting for the other seat is forbidden
+	w := httptest.NewRecorder()
+	r := newQuitRequest("gameID1", "1")
+	r.Header.Set("Authorization", "Bearer token0")
+	QuitGame(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "0"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateQuit, stored.State)
+	assert.Nil(t, stored.Winner)
+	assert.Equal(t, "player1", stored.Forfeit.Player)
+}
+
+func newQuitRequest(gameID, playerID string) *http.Request {
+	r := &http.Request{
+		Method: http.MethodPut,
+		URL: &url.URL{
+			Path: "/tictactoe/" + gameID + "/" + playerID + "/quit",
+		},
+		Header: http.Header{"Authorization": []string{"Bearer token" + playerID}},
+	}
+
+	return mux.SetURLVars(r, map[string]string{
+		"game_id":   gameID,
+		"player_id": playerID,
+	})
+}
+
 func generateGames() []database.Game {
 	newBoard := [][]int{}
 	for i := 0; i < 3; i++ {
@@ -0,0 +1,587 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
+)
+
+/*
+	The lobby lets strangers find each other, in two ways
+	  1) Open seats: a game created with a single player waits in the lobby, and anyone can join it
+	  2) The match queue: players queue up with the board they want and are paired with the next player asking for the same board
+*/
+
+// lobbyGame is how a game waiting for players is listed in the lobby
+type lobbyGame struct {
+	GameID    string   `json:"gameId"`
+	Players   []string `json:"players"`
+	OpenSeats int      `json:"openSeats"`
+	Columns   int      `json:"columns"`
+	Rows      int      `json:"rows"`
+	WinLength int      `json:"winLength"`
+
+	TimeControl *database.TimeControl `json:"timeControl,omitempty"`
+	Gravity     bool                  `json:"gravity,omitempty"`
+	RuleSet     database.RuleSet      `json:"ruleSet,omitempty"`
+}
+
+/*
+	RetrieveLobby lists every game with an open seat
+
+	GET /tictactoe/lobby
+
+	Example Response
+		{
+			"error": null,
+			"data": {"games": [{"gameId": "gameUUID", "players": ["player1"], "openSeats": 1, "columns": 3, "rows": 3, "winLength": 3}]}
+		}
+
+	StatusCodes
+	  200 Ok
+	  500 InternalServerError
+*/
+func RetrieveLobby(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	games, err := dbClient.GetAllGames()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	waitingGames := []lobbyGame{}
+	for _, game := range games {
+		if game.State != database.StateWaitingForPlayers {
+			continue
+		}
+
+		players := []string{}
+		for seat := 0; seat < playersPerGame; seat++ {
+			if player, ok := game.Players[seat]; ok {
+				players = append(players, player)
+			}
+		}
+
+		waitingGames = append(waitingGames, lobbyGame{
+			GameID:    game.ID,
+			Players:   players,
+			OpenSeats: playersPerGame - len(players),
+			Columns:   game.Columns,
+			Rows:      game.Rows,
+			WinLength: game.WinLength,
+
+			TimeControl: game.TimeControl,
+			Gravity:     game.Gravity,
+			RuleSet:     game.RuleSet,
+		})
+	}
+
+	response.Data = map[string]interface{}{
+		"games": waitingGames,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	JoinGame takes the first open seat of a game waiting in the lobby
+	The game starts as soon as its last seat is taken
+
+	POST /tictactoe/{game_id}/join
+
+	Example Request
+		{
+			"player": "player2"
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {"gameId": "gameUUID", "playerId": 1, "token": "token for the seat"}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  404 NotFound
+	  409 Conflict, the game is not waiting for players
+	  500 InternalServerError
+*/
+func JoinGame(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type JoinRequest struct {
+		Player string `json:"player" validate:"required"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	joinRequest := JoinRequest{}
+	err = json.Unmarshal(requestBody, &joinRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(joinRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	vars := mux.Vars(r)
+	gameID, ok := vars["game_id"]
+	if !ok {
+		http.Error(w, "game_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "game_id not provided"
+		return
+	}
+
+	seat := -1
+	token := ""
+	_, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateWaitingForPlayers {
+			return newStatusError(http.StatusConflict, "Game %s is %s, it has no open seat", gameID, game.State)
+		}
+
+		// take the first open seat
+		for seat = 0; seat < playersPerGame; seat++ {
+			if _, ok := game.Players[seat]; !ok {
+				break
+			}
+		}
+		game.Players[seat] = joinRequest.Player
+
+		tokens, err := issueSeatTokens(game)
+		if err != nil {
+			return newStatusError(http.StatusInternalServerError, "Failed to create a token for the seat. %s", err.Error())
+		}
+		token = tokens[seat]
+
+		if len(game.Players) == playersPerGame {
+			if err := startGame(game); err != nil {
+				return newStatusError(http.StatusInternalServerError, "Failed to start the game. %s", err.Error())
+			}
+		}
+
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"gameId":   gameID,
+		"playerId": seat,
+		"token":    token,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ticketTTL is how long a ticket lives without being checked. A player who stopped checking left without saying so,
+// and is never paired into a game nobody answers. A matched ticket is dropped as well, and its game is called off
+// since nobody can ever play the seat
+const ticketTTL = 30 * time.Second
+
+// matchTicket is a player's place in the match queue
+type matchTicket struct {
+	id        string
+	player    string
+	rows      int
+	columns   int
+	winLength int
+
+	// lastSeen is when the ticket was created, last checked, or matched
+	lastSeen time.Time
+
+	// set once the ticket was paired into a game, and handed to the player the next time they check the ticket
+	matched  bool
+	gameID   string
+	playerID int
+	token    string
+}
+
+// matchmaker pairs queued players asking for the same board
+type matchmaker struct {
+
+	// a channel for locking the tickets
+	channelLock chan bool
+
+	tickets map[string]*matchTicket
+
+	// waiting holds the tickets not paired yet, in the order they joined the queue
+	waiting []*matchTicket
+}
+
+// matchQueue is the match queue shared by every request
+var matchQueue = newMatchmaker()
+
+func newMatchmaker() *matchmaker {
+
+	c := make(chan bool, 1)
+	c <- true
+	return &matchmaker{
+		channelLock: c,
+		tickets:     map[string]*matchTicket{},
+		waiting:     []*matchTicket{},
+	}
+}
+
+/*
+	JoinMatchQueue pairs the player with the longest waiting player asking for the same board
+	When somebody is already waiting, the game is created right away with the waiting player in seat 0.
+	Otherwise the player gets a ticket to check with GET /tictactoe/lobby/queue/{ticket_id} until they are matched.
+	A ticket not checked for 30 seconds is dropped. So is a matched ticket whose seat is not picked up in that time,
+	and its game is then QUIT with a forfeit reason of ABANDONED. Queueing again replaces the player's waiting ticket
+
+	POST /tictactoe/lobby/queue
+
+	Example Request
+		{
+			"player": "player2",
+			"columns": 3,
+			"rows": 3,
+			"winLength": 3 # optional, defaults to the shorter side of the board
+		}
+
+	Example Responses
+		200 {"error": null, "data": {"matched": true, "gameId": "gameUUID", "playerId": 1, "token": "token for the seat"}}
+		202 {"error": null, "data": {"matched": false, "ticketId": "ticketUUID"}}
+
+	StatusCodes
+	  200 Ok
+	  202 Accepted
+	  400 BadRequest
+	  500 InternalServerError
+*/
+func JoinMatchQueue(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type QueueRequest struct {
+		Player    string `json:"player" validate:"required"`
+		Columns   *int   `json:"columns" validate:"required,gte=3,lte=25"`
+		Rows      *int   `json:"rows" validate:"required,gte=3,lte=25"`
+		WinLength *int   `json:"winLength" validate:"omitempty,gte=3"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	queueRequest := QueueRequest{}
+	err = json.Unmarshal(requestBody, &queueRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(queueRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	winLength := defaultWinLength(database.VariantClassic, *queueRequest.Rows, *queueRequest.Columns, queueRequest.WinLength)
+	ok, errMsg := validateWinLength(winLength, *queueRequest.Rows, *queueRequest.Columns)
+	if !ok {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		*response.ErrorMessage = errMsg
+		return
+	}
+
+	<-matchQueue.channelLock
+	defer func() { matchQueue.channelLock <- true }()
+
+	// players who stopped checking their tickets are gone, nobody is paired with them
+	matchQueue.dropStale(now())
+
+	// a player queueing again lost track of their ticket, they are never paired with themselves
+	matchQueue.dropWaiting(queueRequest.Player)
+
+	ticket := &matchTicket{
+		id:        uuid.NewV4().String(),
+		player:    queueRequest.Player,
+		rows:      *queueRequest.Rows,
+		columns:   *queueRequest.Columns,
+		winLength: winLength,
+		lastSeen:  now(),
+	}
+
+	opponent := matchQueue.takeWaiting(ticket)
+	if opponent == nil {
+		matchQueue.tickets[ticket.id] = ticket
+		matchQueue.waiting = append(matchQueue.waiting, ticket)
+
+		response.Data = map[string]interface{}{
+			"matched":  false,
+			"ticketId": ticket.id,
+		}
+		response.ErrorMessage = nil
+
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	game := newGame([]string{opponent.player, ticket.player}, ticket.rows, ticket.columns, ticket.winLength)
+	tokens, err := issueSeatTokens(&game)
+	if err == nil {
+		err = startGame(&game)
+	}
+	if err == nil {
+		_, err = dbClient.CreateNewGame(game)
+	}
+	if err != nil {
+		// the waiting player keeps their place at the front of the queue
+		matchQueue.waiting = append([]*matchTicket{opponent}, matchQueue.waiting...)
+
+		fmt.Printf("Failed to create a matched game: %s\n", err.Error())
+		http.Error(w, "InternalServerError handling creation of new game", http.StatusInternalServerError)
+		*response.ErrorMessage = "InternalServerError handling creation of new game"
+		return
+	}
+
+	// the waiting player gets a full ticketTTL to pick up their seat
+	opponent.matched = true
+	opponent.gameID = game.ID
+	opponent.playerID = 0
+	opponent.token = tokens[0]
+	opponent.lastSeen = now()
+
+	response.Data = map[string]interface{}{
+		"matched":  true,
+		"gameId":   game.ID,
+		"playerId": 1,
+		"token":    tokens[1],
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	RetrieveMatchTicket checks if a queued player was matched yet, keeping the ticket alive for another 30 seconds
+	Once matched, the game and the seat's token are returned exactly once and the ticket is gone
+
+	GET /tictactoe/lobby/queue/{ticket_id}
+
+	Example Responses
+		{"error": null, "data": {"matched": false, "ticketId": "ticketUUID"}}
+		{"error": null, "data": {"matched": true, "gameId": "gameUUID", "playerId": 0, "token": "token for the seat"}}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  404 NotFound
+*/
+func RetrieveMatchTicket(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	vars := mux.Vars(r)
+	ticketID, ok := vars["ticket_id"]
+	if !ok {
+		http.Error(w, "ticket_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "ticket_id not provided"
+		return
+	}
+
+	<-matchQueue.channelLock
+	defer func() { matchQueue.channelLock <- true }()
+
+	matchQueue.dropStale(now())
+
+	ticket, ok := matchQueue.tickets[ticketID]
+	if !ok {
+		e := fmt.Errorf("No ticket exists with provided ticket_id %s", ticketID)
+		http.Error(w, e.Error(), http.StatusNotFound)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if !ticket.matched {
+		ticket.lastSeen = now()
+		response.Data = map[string]interface{}{
+			"matched":  false,
+			"ticketId": ticket.id,
+		}
+	} else {
+		delete(matchQueue.tickets, ticketID)
+		response.Data = map[string]interface{}{
+			"matched":  true,
+			"gameId":   ticket.gameID,
+			"playerId": ticket.playerID,
+			"token":    ticket.token,
+		}
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	LeaveMatchQueue takes a player that was not matched yet out of the queue
+
+	DELETE /tictactoe/lobby/queue/{ticket_id}
+
+	Example Response
+		{
+			"error": null,
+			"data": {"leftQueue": "ticketUUID"}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  404 NotFound
+	  409 Conflict, the ticket was already matched into a game
+*/
+func LeaveMatchQueue(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	vars := mux.Vars(r)
+	ticketID, ok := vars["ticket_id"]
+	if !ok {
+		http.Error(w, "ticket_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "ticket_id not provided"
+		return
+	}
+
+	<-matchQueue.channelLock
+	defer func() { matchQueue.channelLock <- true }()
+
+	matchQueue.dropStale(now())
+
+	ticket, ok := matchQueue.tickets[ticketID]
+	if !ok {
+		e := fmt.Errorf("No ticket exists with provided ticket_id %s", ticketID)
+		http.Error(w, e.Error(), http.StatusNotFound)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if ticket.matched {
+		e := fmt.Errorf("Ticket %s was already matched into game %s, check the ticket to pick up the seat", ticketID, ticket.gameID)
+		http.Error(w, e.Error(), http.StatusConflict)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	matchQueue.remove(ticket)
+
+	response.Data = map[string]interface{}{
+		"leftQueue": ticketID,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// takeWaiting removes and returns the longest waiting ticket asking for the same board as ticket, or nil
+// The caller must hold the channelLock
+func (m *matchmaker) takeWaiting(ticket *matchTicket) *matchTicket {
+
+	for i, waiting := range m.waiting {
+		if waiting.player != ticket.player && waiting.rows == ticket.rows && waiting.columns == ticket.columns && waiting.winLength == ticket.winLength {
+			m.waiting = append(m.waiting[:i], m.waiting[i+1:]...)
+			return waiting
+		}
+	}
+
+	return nil
+}
+
+// dropStale removes every ticket that was not seen for longer than ticketTTL at the given time, matched or not
+// The game of a matched ticket is called off, its seat can never be played. The caller must hold the channelLock
+func (m *matchmaker) dropStale(at time.Time) {
+
+	for _, ticket := range m.tickets {
+		if at.Sub(ticket.lastSeen) <= ticketTTL {
+			continue
+		}
+
+		m.remove(ticket)
+		if ticket.matched {
+			abandonMatchedGame(ticket.gameID, ticket.playerID)
+		}
+	}
+}
+
+// dropWaiting removes the tickets player is still waiting with. The caller must hold the channelLock
+func (m *matchmaker) dropWaiting(player string) {
+
+	for _, ticket := range m.tickets {
+		if !ticket.matched && ticket.player == player {
+			m.remove(ticket)
+		}
+	}
+}
+
+// remove takes ticket out of the tickets and out of the waiting line. The caller must hold the channelLock
+func (m *matchmaker) remove(ticket *matchTicket) {
+
+	delete(m.tickets, ticket.id)
+	for i, waiting := range m.waiting {
+		if waiting == ticket {
+			m.waiting = append(m.waiting[:i], m.waiting[i+1:]...)
+			break
+		}
+	}
+}
+
+// abandonMatchedGame calls off the matched game gameID whose seat playerID was never picked up
+// A game that somehow got going is left alone
+func abandonMatchedGame(gameID string, playerID int) {
+
+	_, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateInProgress || len(game.Moves) > 0 {
+			return newStatusError(http.StatusConflict, "Game %s is %s with %d moves", gameID, game.State, len(game.Moves))
+		}
+
+		forfeitGame(game, playerID, database.ForfeitReasonAbandoned)
+		return nil
+	})
+	if e != nil {
+		fmt.Printf("Failed to call off matched game %s: %s\n", gameID, e.Error())
+	}
+}
@@ -0,0 +1,204 @@
+package apiresources
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// A test file for only lobby.go
+
+func TestJoinGameStartsGame(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game {
+		return newGame([]string{"player1"}, 3, 3, 3)
+	}, nil)
+
+	var stored database.Game
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/tictactoe/gameID1/join", bytes.NewBufferString(`{"player": "player2"}`))
+	JoinGame(w, mux.SetURLVars(r, map[string]string{"game_id": "gameID1"}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateInProgress, stored.State)
+	assert.Equal(t, "player2", stored.Players[1])
+	assert.Contains(t, stored.TokenHashes, 1)
+}
+
+func TestJoinGameNoOpenSeat(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game { return generateGameWithBoard(3, 3, 3) }, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/tictactoe/gameID1/join", bytes.NewBufferString(`{"player": "player3"}`))
+	JoinGame(w, mux.SetURLVars(r, map[string]string{"game_id": "gameID1"}))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	dbMock.AssertNotCalled(t, "CompareAndSwapGame", mock.Anything)
+}
+
+func TestMatchQueuePairsSameBoard(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+	matchQueue = newMatchmaker()
+
+	var stored database.Game
+	dbMock.On("CreateNewGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return("gameID1", nil)
+
+	// nobody is waiting for the first player
+	w := httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player1", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	ticketID := decodeResponseData(t, w)["ticketId"].(string)
+
+	// a different board does not match
+	w = httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player2", "rows": 4, "columns": 4}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// the same board does
+	w = httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player3", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[int]string{0: "player1", 1: "player3"}, stored.Players)
+	assert.Equal(t, database.StateInProgress, stored.State)
+
+	// the waiting player picks up their seat exactly once
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+ticketID, nil), map[string]string{"ticket_id": ticketID}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := decodeResponseData(t, w)
+	assert.Equal(t, true, data["matched"])
+	assert.Equal(t, float64(0), data["playerId"])
+	assert.Equal(t, stored.TokenHashes[0], hashSeatToken(data["token"].(string)))
+
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+ticketID, nil), map[string]string{"ticket_id": ticketID}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMatchQueueDropsStaleTickets(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+	matchQueue = newMatchmaker()
+
+	var stored database.Game
+	dbMock.On("CreateNewGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return("gameID1", nil)
+
+	start := time.Now()
+	defer setNow(start)()
+
+	w := httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player1", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	goneID := decodeResponseData(t, w)["ticketId"].(string)
+
+	// player1 left without a word, player2 is not paired with them
+	setNow(start.Add(ticketTTL + time.Second))
+	w = httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player2", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	waitingID := decodeResponseData(t, w)["ticketId"].(string)
+
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+goneID, nil), map[string]string{"ticket_id": goneID}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// checking the ticket keeps player2 in the queue past the first ticketTTL
+	setNow(start.Add(ticketTTL * 3 / 2))
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+waitingID, nil), map[string]string{"ticket_id": waitingID}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	setNow(start.Add(ticketTTL * 2))
+	w = httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player3", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[int]string{0: "player2", 1: "player3"}, stored.Players)
+
+	dbMock.On("GetGameWithID", stored.ID).Return(func(string) database.Game { return stored }, nil)
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	// a seat nobody picks up does not keep its token around, and its game is called off
+	setNow(start.Add(ticketTTL*3 + time.Second))
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+waitingID, nil), map[string]string{"ticket_id": waitingID}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, matchQueue.tickets)
+	assert.Empty(t, matchQueue.waiting)
+
+	assert.Equal(t, database.StateQuit, stored.State)
+	assert.Nil(t, stored.Winner)
+	assert.Equal(t, &database.Forfeit{Player: "player2", Reason: database.ForfeitReasonAbandoned}, stored.Forfeit)
+}
+
+func TestMatchQueueReplacesTicketOfSamePlayer(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+	matchQueue = newMatchmaker()
+
+	w := httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player1", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	firstID := decodeResponseData(t, w)["ticketId"].(string)
+
+	// queueing again does not pair player1 with themselves
+	w = httptest.NewRecorder()
+	JoinMatchQueue(w, httptest.NewRequest(http.MethodPost, "/tictactoe/lobby/queue", bytes.NewBufferString(`{"player": "player1", "rows": 3, "columns": 3}`)))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	secondID := decodeResponseData(t, w)["ticketId"].(string)
+
+	w = httptest.NewRecorder()
+	RetrieveMatchTicket(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tictactoe/lobby/queue/"+firstID, nil), map[string]string{"ticket_id": firstID}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Len(t, matchQueue.waiting, 1)
+	assert.Equal(t, secondID, matchQueue.waiting[0].id)
+	dbMock.AssertNotCalled(t, "CreateNewGame", mock.Anything)
+}
+
+// decodeResponseData returns the data of a Response written to w
+func decodeResponseData(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+
+	body, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	response := Response{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+
+	return response.Data
+}
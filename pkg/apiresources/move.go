@@ -1,6 +1,7 @@
 package apiresources
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
 )
 
@@ -191,7 +193,8 @@ func RetrieveAMove(w http.ResponseWriter, r *http.Request) {
 
 /*
 	PostAMove posts a move to the current game provided a game_id and player_id
-	player_id is just either 0 or 1
+	player_id is the seat, and the request must carry that seat's bearer token.
+	Every variant takes its own fields, see the README
 
 	POST /tictactoe/{game_id}/{player_id}
 
@@ -206,15 +209,19 @@ func RetrieveAMove(w http.ResponseWriter, r *http.Request) {
 			"error": null,
 			"data" : {
 				"move": "{gameId}/moves/{move_number}"
+				"botMove": "{gameId}/moves/{move_number}" // omitempty
 				"winner": "player1" // omitempty
+				"drawReason": "NO_WINNABLE_LINE" // omitempty
 			}
 		}
 
 	StatusCodes
 	  200 Ok
 	  400 BadRequest
+	  401 Unauthorized
+	  403 Forbidden
 	  404 NotFound
-	  409 NotPlayersTurn
+	  409 NotPlayersTurn, or the game can not take a move right now
 	  500 InternalServerError
 */
 func PostAMove(w http.ResponseWriter, r *http.Request) {
@@ -222,13 +229,6 @@ func PostAMove(w http.ResponseWriter, r *http.Request) {
 	response := Response{ErrorMessage: new(string)}
 	defer json.NewEncoder(w).Encode(&response)
 
-	type MoveRequest struct {
-		Column *int `json:"column" validate:"required,lte=2,gte=0"`
-		Row    *int `json:"row" validate:"required,lte=2,gte=0"`
-	}
-
-	v := validator.New()
-
 	requestBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -236,14 +236,6 @@ func PostAMove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	moveRequest := MoveRequest{}
-	err = json.Unmarshal(requestBody, &moveRequest)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		*response.ErrorMessage = fmt.Sprintf("Failed to unmarshal data, moveRequest is malformed. %s", err.Error())
-		return
-	}
-
 	// Retrieve game_id and player_id and validate them
 	vars := mux.Vars(r)
 	gameID, ok := vars["game_id"]
@@ -266,97 +258,293 @@ func PostAMove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	game, err := dbClient.GetGameWithID(gameID)
-	if err != nil || game.State == database.StateComplete || game.State == database.StateQuit {
-		fmt.Printf("Failed to find game with gameID %s. Err: %s\n", gameID, err.Error())
-		http.Error(w, fmt.Sprintf("Failed to find an IN_PROGRESS game with this gameID. %s", err.Error()), http.StatusNotFound)
-		*response.ErrorMessage = err.Error()
-		return
-	}
+	moveNumber := -1
+	botMoveNumber := -1
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateInProgress {
+			return newStatusError(http.StatusNotFound, "Failed to find an IN_PROGRESS game with this gameID. Game %s is %s", gameID, game.State)
+		}
+
+		// Player not found
+		if _, ok := game.Players[playerID]; !ok {
+			return newStatusError(http.StatusNotFound, "Player with playerID %d is not found\n", playerID)
+		}
+
+		// Players who quit a party game are out of it
+		if hasQuit(game, playerID) {
+			return newStatusError(http.StatusConflict, "Player %d quit the game\n", playerID)
+		}
+
+		// The computer plays its own seat
+		if _, ok := game.Bots[playerID]; ok {
+			return newStatusError(http.StatusBadRequest, "Player %d is played by the computer\n", playerID)
+		}
+
+		// Only the holder of the seat's token may move for it
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		// Not the current player's turn
+		if game.NextPlayerIdx != -1 && game.NextPlayerIdx != playerID {
+			return newStatusError(http.StatusConflict, "Is is not player %d's turn\n", playerID)
+		}
+
+		// The move came too late, the player lost on time before making it
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		// the stones of a swap opening are placed and the colors chosen before moves are played one at a time
+		if game.OpeningStage != "" {
+			return newStatusError(http.StatusConflict, "The opening of game %s is not over, it is played with its own requests\n", gameID)
+		}
+
+		// the fields of the request depend on the variant, so the game decodes them
+		var e *statusError
+		moveNumber, e = postVariantMove(game, playerID, requestBody)
+		if e != nil {
+			return e
+		}
+
+		// let the computer reply straight away if it holds the other seat
+		var err error
+		botMoveNumber, err = playBotMove(game)
+		if err != nil {
+			return newStatusError(http.StatusInternalServerError, "Failed to play the computer's reply. %s\n", err.Error())
+		}
 
-	// Player not found
-	if _, ok := game.Players[playerID]; !ok {
-		e := fmt.Errorf("Player with playerID %d is not found\n", playerID)
-		http.Error(w, e.Error(), http.StatusNotFound)
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
 		*response.ErrorMessage = e.Error()
 		return
 	}
 
-	// Not the current player's turn
-	if game.NextPlayerIdx != -1 && game.NextPlayerIdx != playerID {
-		e := fmt.Errorf("Is is not player %d's turn\n", playerID)
-		http.Error(w, e.Error(), http.StatusNotFound)
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "Player %d ran out of time, %s wins the game", playerID, *game.Winner)
+		http.Error(w, e.Error(), e.status)
 		*response.ErrorMessage = e.Error()
 		return
 	}
 
-	errStr := v.ValidateStruct(moveRequest)
-	if errStr != nil {
-		http.Error(w, *errStr, http.StatusBadRequest)
-		response.ErrorMessage = errStr
-		return
+	response.Data = map[string]interface{}{
+		"move": fmt.Sprintf("%s/moves/%d", gameID, moveNumber),
 	}
 
-	moveNumber, err := playMove(*moveRequest.Row, *moveRequest.Column, playerID, &game)
-	if err != nil {
-		e := fmt.Errorf("Failed to play the move, it is illegal. %s\n", err.Error())
-		http.Error(w, e.Error(), http.StatusBadRequest)
-		*response.ErrorMessage = e.Error()
-		return
+	if botMoveNumber != -1 {
+		response.Data["botMove"] = fmt.Sprintf("%s/moves/%d", gameID, botMoveNumber)
 	}
 
-	response.Data = map[string]interface{}{
-		"move": fmt.Sprintf("%s/moves/%d", gameID, moveNumber),
+	// store the winner in the response
+	if game.Winner != nil {
+		response.Data["winner"] = *game.Winner
+	}
+	if game.DrawReason != "" {
+		response.Data["drawReason"] = game.DrawReason
 	}
 
-	// Store next player
-	if game.NextPlayerIdx == -1 {
-		if playerID == 0 {
-			game.NextPlayerIdx = 1
-		} else {
-			game.NextPlayerIdx = 0
-		}
-	} else if game.NextPlayerIdx == 0 {
-		game.NextPlayerIdx = 1
-	} else {
-		game.NextPlayerIdx = 0
-	}
-
-	// check the board for a winner and store the winner in the response
-	if checkBoardForWinner(*moveRequest.Row, *moveRequest.Column, playerID, &game) {
-		fmt.Printf("Winner! player: %s\n", game.Players[playerID])
-		game.State = database.StateComplete
-		response.Data["winner"] = game.Players[playerID]
-	} else {
-		// There is no winner, if the number of moves = 9, then we know we have a DRAW and there is no winner
-		// NOTE this is for a strict 3x3 board only. The real check would be against game.Rows*game.Columns
-		if len(game.Moves) == 9 {
-			// winner remains null
+	response.ErrorMessage = nil
+	w.WriteHeader(http.StatusOK)
+}
+
+// applyMove plays the move for playerID, hands the turn to the other player and completes the game
+// if the move won or filled the board. Returns the moveNumber and/or an error
+func applyMove(row, col, playerID int, game *database.Game) (int, error) {
+	return applyMoveWithMark(row, col, playerID, playerMark(game, playerID), game)
+}
+
+// applyMoveWithMark is applyMove placing mark rather than the player's own, as either player may place either symbol in wild
+// and numerical places numbers
+func applyMoveWithMark(row, col, playerID, mark int, game *database.Game) (int, error) {
+
+	moveNumber, err := playMove(row, col, playerID, mark, game)
+	if err != nil {
+		return -1, err
+	}
+
+	// moving on answers a takeback with a no, and so does the opponent of a draw offer
+	game.PendingTakeback = nil
+	if game.DrawOfferedBy != nil && *game.DrawOfferedBy != playerID {
+		game.DrawOfferedBy = nil
+	}
+
+	// Store next player, turns rotate in seat order skipping the players who quit
+	game.NextPlayerIdx = nextSeat(game, playerID)
+
+	switch game.Variant {
+	case database.VariantQubic:
+		settleQubic(game)
+	case database.VariantWild:
+		settleWild(game, row, col, playerID)
+	case database.VariantOrderAndChaos:
+		settleOrderAndChaos(game, row, col)
+	case database.VariantNumerical:
+		settleNumerical(game, row, col, playerID)
+	default:
+		// check the board for a winner, under misere and notakto the player completing a line loses
+		if winnerIdx, decided := checkBoardForWinner(row, col, playerID, game); decided {
+			fmt.Printf("Winner! player: %s\n", game.Players[winnerIdx])
+			winner := game.Players[winnerIdx]
+			game.State = database.StateComplete
+			game.Winner = &winner
+		} else if engine.IsFull(game.GameBoard) {
+			// There is no winner and no square left to play, so we have a DRAW. winner remains null
+			game.State = database.StateComplete
+			game.DrawReason = database.DrawReasonBoardFull
+		} else if !gamePosition(game).CanStillBeWon() {
+			// Squares are left, but no line can be completed by anybody any more. Playing them out would change nothing
 			game.State = database.StateComplete
+			game.DrawReason = database.DrawReasonNoWinnableLine
 		}
 	}
 
-	// Update the move in the DB
-	err = dbClient.UpdateGame(game)
+	pressClock(game, playerID)
+
+	return moveNumber, nil
+}
+
+// postVariantMove decodes the move of the game's variant from body and plays it for playerID. Returns the moveNumber
+func postVariantMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	switch {
+	case game.Variant == database.VariantQuantum:
+		return postQuantumMove(game, playerID, body)
+	case game.Variant == database.VariantUltimate:
+		return postUltimateMove(game, playerID, body)
+	case game.Variant == database.VariantQubic:
+		return postQubicMove(game, playerID, body)
+	case game.Variant == database.VariantNumerical:
+		return postNumericalMove(game, playerID, body)
+	case placesSymbols(game):
+		return postSymbolMove(game, playerID, body)
+	case game.RuleSet == database.RuleSetNotakto:
+		return postNotaktoMove(game, playerID, body)
+	}
+
+	return postClassicMove(game, playerID, body)
+}
+
+// decodeMoveRequest fills moveRequest, the request type of the game's variant, from body and validates it
+// Fields the variant does not take are refused rather than ignored, takes lists the ones it does for the error
+func decodeMoveRequest(game *database.Game, body []byte, moveRequest interface{}, takes string) *statusError {
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(moveRequest); err != nil {
+		return newStatusError(http.StatusBadRequest, "Failed to unmarshal data, a move of game %s takes %s. %s", game.ID, takes, err.Error())
+	}
+
+	v := validator.New()
+	if errStr := v.ValidateStruct(moveRequest); errStr != nil {
+		return newStatusError(http.StatusBadRequest, "A move of game %s takes %s. %s", game.ID, takes, *errStr)
+	}
+
+	return nil
+}
+
+// postClassicMove plays the row and column of the request body for playerID, only the column in a gravity game
+func postClassicMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type MoveRequest struct {
+		Row    *int `json:"row" validate:"omitempty,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	moveRequest := MoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "a row and a column"); e != nil {
+		return -1, e
+	}
+
+	row, col, e := boardSquare(game, moveRequest.Row, *moveRequest.Column)
+	if e != nil {
+		return -1, e
+	}
+
+	return placeMark(game, playerID, row, col, playerMark(game, playerID))
+}
+
+// postNotaktoMove plays the board, row and column of the request body for playerID
+// The boards are stacked into the GameBoard, and a game of a single board does not need one
+func postNotaktoMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type NotaktoMoveRequest struct {
+		Board  *int `json:"board" validate:"omitempty,gte=0"`
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	moveRequest := NotaktoMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "a row and a column, and a board when there are several"); e != nil {
+		return -1, e
+	}
+
+	board := 0
+	if moveRequest.Board != nil {
+		board = *moveRequest.Board
+	}
+	if board >= game.Boards {
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. board provided (%d) is out of range [0-%d]", board, game.Boards-1)
+	}
+	if *moveRequest.Row >= game.Rows {
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. row provided (%d) is out of range [0-%d]", *moveRequest.Row, game.Rows-1)
+	}
+
+	return placeMark(game, playerID, board*game.Rows+*moveRequest.Row, *moveRequest.Column, playerMark(game, playerID))
+}
+
+// boardSquare returns the row and column of the GameBoard a move on a single board lands on
+// In a gravity game the move only takes a column, and the row is the lowest empty row of it
+func boardSquare(game *database.Game, row *int, col int) (int, int, *statusError) {
+
+	if !game.Gravity {
+		if row == nil {
+			return -1, -1, newStatusError(http.StatusBadRequest, "row is required")
+		}
+		return *row, col, nil
+	}
+
+	if row != nil {
+		return -1, -1, newStatusError(http.StatusBadRequest, "Game %s has gravity, a move only takes a column", game.ID)
+	}
+
+	if col >= game.Columns {
+		return -1, -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. col provided (%d) is out of range [0-%d]", col, game.Columns-1)
+	}
+
+	dropRow := engine.DropRow(game.GameBoard, col)
+	if dropRow == -1 {
+		return -1, -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. column %d is full", col)
+	}
+
+	return dropRow, col, nil
+}
+
+// placeMark plays mark at row, col of the GameBoard for playerID, refusing an illegal move. Returns the moveNumber
+func placeMark(game *database.Game, playerID, row, col, mark int) (int, *statusError) {
+
+	moveNumber, err := applyMoveWithMark(row, col, playerID, mark, game)
 	if err != nil {
-		e := fmt.Errorf("Failed to update the game in the DB. %s\n", err.Error())
-		http.Error(w, e.Error(), http.StatusInternalServerError)
-		*response.ErrorMessage = e.Error()
-		return
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. %s\n", err.Error())
 	}
 
-	response.ErrorMessage = nil
-	w.WriteHeader(http.StatusOK)
+	return moveNumber, nil
 }
 
-// try to play the move, return a moveNumber and/or and error
-func playMove(row, col, playerID int, game *database.Game) (int, error) {
+// try to play the move placing mark for playerID, return a moveNumber and/or and error
+func playMove(row, col, playerID, mark int, game *database.Game) (int, error) {
 
-	if row > game.Rows || row < 0 {
-		return -1, fmt.Errorf("row provided (%d) is out of range [0-%d]", row, game.Rows-1)
+	// the board of a three dimensional game holds the rows of every layer
+	if row >= len(game.GameBoard) || row < 0 {
+		return -1, fmt.Errorf("row provided (%d) is out of range [0-%d]", row, len(game.GameBoard)-1)
 	}
 
-	if col > game.Columns || col < 0 {
+	if col >= game.Columns || col < 0 {
 		return -1, fmt.Errorf("col provided (%d) is out of range [0-%d]", col, game.Columns-1)
 	}
 
@@ -364,92 +552,85 @@ func playMove(row, col, playerID int, game *database.Game) (int, error) {
 		return -1, fmt.Errorf("move with row %d and col %d is already taken", row, col)
 	}
 
-	// Assign the square to the playerID
-	game.GameBoard[row][col] = playerID
+	if game.Gravity && engine.DropRow(game.GameBoard, col) != row {
+		return -1, fmt.Errorf("move with row %d and col %d would float, pieces drop to row %d", row, col, engine.DropRow(game.GameBoard, col))
+	}
+
+	if game.Opening == database.OpeningPro {
+		if err := checkProMove(game, row, col); err != nil {
+			return -1, err
+		}
+	}
+
+	if game.RuleSet == database.RuleSetNotakto && !gamePosition(game).IsLegalMove(row, col) {
+		return -1, fmt.Errorf("board %d is dead, a line on it is already complete", row/game.Rows)
+	}
+
+	// Assign the square to the playerID, or to the symbol placed in wild, the number in numerical or the color of the stone
+	game.GameBoard[row][col] = mark
 
 	// make note of the move
-	game.Moves = append(game.Moves, database.Move{
+	move := database.Move{
 		Type:   database.MoveTypeMove,
 		Player: game.Players[playerID],
 		Row:    row,
 		Col:    col,
-	})
+	}
+	if game.Layers > 0 {
+		layer := row / game.Rows
+		move.Layer = &layer
+		move.Row = row % game.Rows
+	}
+	if game.Boards > 0 {
+		board := row / game.Rows
+		move.Board = &board
+		move.Row = row % game.Rows
+	}
+	if placesSymbols(game) {
+		move.Symbol = wildSymbols[mark]
+	}
+	if game.Opening != "" {
+		move.Color = stoneColors[mark]
+	}
+	if game.Variant == database.VariantNumerical {
+		move.Value = mark
+		useNumber(game, playerID, mark)
+	}
+	game.Moves = append(game.Moves, move)
 
 	// return the move number, which is offset by 0
 	return len(game.Moves) - 1, nil
 }
 
-func checkBoardForWinner(row, col, playerID int, game *database.Game) bool {
-
-	// Check Left to Right
-	squareCount := game.Columns
-	colIdx := col
-	for i := 1; i <= game.Columns; i++ {
-		colIdx = colIdx % game.Columns
-		if game.GameBoard[row][colIdx] != playerID {
-			break
-		}
-		squareCount--
-		colIdx++
-	}
-
-	if squareCount == 0 {
-		// found winner!
-		return true
-	}
-
-	// Check Up and Down
-	squareCount = game.Rows
-	rowIdx := row
-	for i := 1; i <= game.Rows; i++ {
-		rowIdx = rowIdx % game.Rows
-		if game.GameBoard[rowIdx][col] != playerID {
-			break
-		}
-		squareCount--
-		rowIdx++
-	}
+// checkBoardForWinner judges the move playerID made at row, col by the rule set of the game
+// Returns the index into the Player array of the winner, and true when the move decided the game
+func checkBoardForWinner(row, col, playerID int, game *database.Game) (int, bool) {
 
-	if squareCount == 0 {
-		// found winner!
-		return true
+	if game.GameBoard[row][col] != playerMark(game, playerID) {
+		return -1, false
 	}
 
-	// TopLeft to BottomRight Diagonal
-	squareCount = game.Rows
-	r := 0
-	c := 0
-	for r < game.Rows {
-		if game.GameBoard[r][c] != playerID {
-			break
-		}
-		squareCount--
-		r++
-		c++
+	switch gamePosition(game).MoveOutcome(row, col) {
+	case engine.OutcomeWin:
+		return playerID, true
+	case engine.OutcomeLoss:
+		// only rule sets other than standard lose on a move, and validatePartyGame keeps those to two players
+		return nextSeat(game, playerID), true
 	}
 
-	if squareCount == 0 {
-		// found winner!
-		return true
-	}
+	return -1, false
+}
 
-	// TopRight to BottomLeft Diagonal
-	squareCount = game.Rows
-	r = 0
-	c = game.Columns - 1
-	for r < game.Rows {
-		if game.GameBoard[r][c] != playerID {
-			break
-		}
-		squareCount--
-		r++
-		c--
-	}
+// boardRow returns the row of the GameBoard a move was played on
+// Three dimensional games record the row within the layer, and notakto the row within the board
+func boardRow(game *database.Game, move database.Move) int {
 
-	if squareCount == 0 {
-		// found winner!
-		return true
+	switch {
+	case move.Layer != nil:
+		return *move.Layer*game.Rows + move.Row
+	case move.Board != nil:
+		return *move.Board*game.Rows + move.Row
 	}
 
-	return false
+	return move.Row
 }
@@ -0,0 +1,322 @@
+package apiresources
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// A test file for only move.go
+
+func TestCheckBoardForWinnerClassicBoard(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.GameBoard[0][0] = 0
+	game.GameBoard[1][1] = 0
+	game.GameBoard[2][2] = 0
+
+	winner, decided := checkBoardForWinner(1, 1, 0, &game)
+	assert.True(t, decided)
+	assert.Equal(t, 0, winner)
+
+	_, decided = checkBoardForWinner(1, 1, 1, &game)
+	assert.False(t, decided)
+}
+
+func TestCheckBoardForWinnerLargeBoard(t *testing.T) {
+
+	game := generateGameWithBoard(15, 15, 5)
+
+	// four in a row on the anti diagonal is not enough
+	for i := 0; i < 4; i++ {
+		game.GameBoard[3+i][10-i] = 1
+	}
+	_, decided := checkBoardForWinner(5, 8, 1, &game)
+	assert.False(t, decided)
+
+	// completing the line is found from any square along it, not just at the ends
+	game.GameBoard[7][6] = 1
+	winner, decided := checkBoardForWinner(5, 8, 1, &game)
+	assert.True(t, decided)
+	assert.Equal(t, 1, winner)
+
+	// a broken line along a row does not win
+	for _, col := range []int{0, 1, 2, 4, 5} {
+		game.GameBoard[14][col] = 0
+	}
+	_, decided = checkBoardForWinner(14, 2, 0, &game)
+	assert.False(t, decided)
+}
+
+func TestCheckBoardForWinnerMisere(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.RuleSet = database.RuleSetMisere
+	game.GameBoard[0][0] = 0
+	game.GameBoard[1][1] = 0
+	game.GameBoard[2][2] = 0
+
+	// completing the line hands the game to the opponent
+	winner, decided := checkBoardForWinner(1, 1, 0, &game)
+	assert.True(t, decided)
+	assert.Equal(t, 1, winner)
+}
+
+func TestPostAMoveNotakto(t *testing.T) {
+
+	// two 3x3 boards stacked one below the other
+	stored := generateGameWithBoard(6, 3, 3)
+	stored.Rows = 3
+	stored.Boards = 2
+	stored.RuleSet = database.RuleSetNotakto
+
+	storeGame(&stored)
+
+	// both players fill the top row of board 0, which kills it
+	for i, body := range []string{`{"board": 0, "row": 0, "column": 0}`, `{"row": 0, "column": 1}`, `{"board": 0, "row": 0, "column": 2}`} {
+		w := httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", fmt.Sprint(i%2), body))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, database.StateInProgress, stored.State)
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"board": 0, "row": 1, "column": 1}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the left column of board 1 kills the last board, so whoever completes it loses
+	for i, row := range []int{0, 1, 2} {
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", fmt.Sprint((i+1)%2), fmt.Sprintf(`{"board": 1, "row": %d, "column": 0}`, row)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	board := 1
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player2", Row: 0, Col: 0, Board: &board}, stored.Moves[3])
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+}
+
+func TestPlayMoveOutOfRange(t *testing.T) {
+
+	game := generateGameWithBoard(6, 7, 4)
+
+	_, err := playMove(6, 0, 0, 0, &game)
+	assert.Error(t, err)
+
+	_, err = playMove(0, 7, 0, 0, &game)
+	assert.Error(t, err)
+
+	moveNumber, err := playMove(5, 6, 0, 0, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, moveNumber)
+	assert.Equal(t, 0, game.GameBoard[5][6])
+}
+
+func TestApplyMoveDrawsEarly(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.GameBoard = [][]int{
+		{0, 1, 0},
+		{0, 1, 1},
+		{1, -1, -1},
+	}
+	game.NextPlayerIdx = 0
+
+	// blocking the middle column leaves a square open, but no line anybody can complete
+	_, err := applyMove(2, 1, 0, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Nil(t, game.Winner)
+	assert.Equal(t, database.DrawReasonNoWinnableLine, game.DrawReason)
+}
+
+func TestPostAMoveGravity(t *testing.T) {
+
+	stored := generateGameWithBoard(6, 7, 4)
+	stored.Gravity = true
+	for row := 5; row > 0; row-- {
+		stored.GameBoard[row][3] = row % 2
+	}
+
+	storeGame(&stored)
+
+	// the piece drops to the bottom row
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"column": 2}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, stored.GameBoard[5][2])
+
+	// the last square of column 3 is taken, then the column is full
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"column": 3}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, stored.GameBoard[0][3])
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"column": 3}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// gravity games do not take a row
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 5, "column": 4}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostAMoveRefusesFieldsOfOtherVariants(t *testing.T) {
+
+	stored := generateGameWithBoard(3, 3, 3)
+	storeGame(&stored)
+
+	// a classic game takes neither a layer, a symbol nor a value
+	for _, body := range []string{`{"layer": 0, "row": 1, "column": 1}`, `{"row": 1, "column": 1, "symbol": "X"}`, `{"row": 1, "column": 1, "value": 5}`} {
+		w := httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", "0", body))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	assert.Empty(t, stored.Moves)
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, stored.Moves, 1)
+}
+
+func TestPostAMoveRetriesStaleWrite(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	// every read hands out a fresh copy of the game, like the real DB does
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game { return generateGameWithBoard(3, 3, 3) }, nil)
+
+	// another request sneaks in before the first write, the second write goes through
+	dbMock.On("CompareAndSwapGame", mock.Anything).Return(database.ErrVersionConflict).Once()
+	dbMock.On("CompareAndSwapGame", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	dbMock.AssertNumberOfCalls(t, "CompareAndSwapGame", 2)
+}
+
+func TestPostAMoveConflict(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	// every read hands out a fresh copy of the game, like the real DB does
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game { return generateGameWithBoard(3, 3, 3) }, nil)
+	dbMock.On("CompareAndSwapGame", mock.Anything).Return(database.ErrVersionConflict)
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	dbMock.AssertNumberOfCalls(t, "CompareAndSwapGame", maxUpdateAttempts)
+}
+
+func TestPostAMoveWrongSeatToken(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game { return generateGameWithBoard(3, 3, 3) }, nil)
+
+	// player 1's token can not move for player 0
+	w := httptest.NewRecorder()
+	r := newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`)
+	r.Header.Set("Authorization", "Bearer token1")
+	PostAMove(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// a guessed token gets nowhere
+	w = httptest.NewRecorder()
+	r = newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`)
+	r.Header.Set("Authorization", "Bearer guessed")
+	PostAMove(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	dbMock.AssertNotCalled(t, "CompareAndSwapGame", mock.Anything)
+}
+
+func newMoveRequest(gameID, playerID, body string) *http.Request {
+	r := &http.Request{
+		Method: http.MethodPost,
+		URL: &url.URL{
+			Path: "/tictactoe/" + gameID + "/" + playerID,
+		},
+		Header: http.Header{"Authorization": []string{"Bearer token" + playerID}},
+		Body:   ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+
+	return mux.SetURLVars(r, map[string]string{
+		"game_id":   gameID,
+		"player_id": playerID,
+	})
+}
+
+// newActionRequest is a request to one of the seat actions of game gameID1 under /tictactoe/gameID1/{player_id}/{action},
+// carrying the token of the seat
+func newActionRequest(method, playerID, action, body string) *http.Request {
+	r := httptest.NewRequest(method, "/tictactoe/gameID1/"+playerID+"/"+action, bytes.NewBufferString(body))
+	r.Header.Set("Authorization", "Bearer token"+playerID)
+
+	return mux.SetURLVars(r, map[string]string{
+		"game_id":   "gameID1",
+		"player_id": playerID,
+	})
+}
+
+// storeGame backs a new DB mock with stored, the way the DB clients keep a game: every read hands out a copy of it,
+// and every compare-and-swap writes the game back into stored. Returns the mock, to assert on its calls
+func storeGame(stored *database.Game) *mocks.DB {
+
+	dbMock := &mocks.DB{}
+	dbClient = dbMock
+
+	dbMock.On("GetGameWithID", stored.ID).Return(func(string) database.Game { return stored.Copy() }, nil)
+	dbMock.On("CompareAndSwapGame", mock.Anything).Run(func(args mock.Arguments) {
+		*stored = args.Get(0).(database.Game)
+	}).Return(nil)
+
+	return dbMock
+}
+
+func generateGameWithBoard(rows, columns, winLength int) database.Game {
+	newBoard := [][]int{}
+	for i := 0; i < rows; i++ {
+		row := []int{}
+		for j := 0; j < columns; j++ {
+			row = append(row, -1)
+		}
+		newBoard = append(newBoard, row)
+	}
+
+	return database.Game{
+		ID:            "gameID1",
+		Players:       map[int]string{0: "player1", 1: "player2"},
+		Columns:       columns,
+		Rows:          rows,
+		WinLength:     winLength,
+		State:         database.StateInProgress,
+		Moves:         []database.Move{},
+		NextPlayerIdx: -1,
+		GameBoard:     newBoard,
+		TokenHashes:   map[int]string{0: hashSeatToken("token0"), 1: hashSeatToken("token1")},
+	}
+}
@@ -0,0 +1,98 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+// numericalNumbers hands the odd numbers to seat 0, who opens, and the even numbers to seat 1
+func numericalNumbers() map[int][]int {
+	return map[int][]int{
+		0: engine.NumericalNumbers(0),
+		1: engine.NumericalNumbers(1),
+	}
+}
+
+// postNumericalMove places the value of the request body at its row and column for playerID
+func postNumericalMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type NumericalMoveRequest struct {
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+		Value  *int `json:"value" validate:"required,gte=1,lte=9"`
+	}
+
+	moveRequest := NumericalMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, fmt.Sprintf("a row, a column and a value, one of %v", game.Numbers[playerID])); e != nil {
+		return -1, e
+	}
+
+	mark, e := numericalMark(game, playerID, *moveRequest.Value)
+	if e != nil {
+		return -1, e
+	}
+
+	return placeMark(game, playerID, *moveRequest.Row, *moveRequest.Column, mark)
+}
+
+// numericalMark makes sure value is one of the numbers playerID has left to place
+func numericalMark(game *database.Game, playerID int, value int) (int, *statusError) {
+
+	for _, n := range game.Numbers[playerID] {
+		if n == value {
+			return n, nil
+		}
+	}
+
+	return -1, newStatusError(http.StatusBadRequest, "Player %d has no %d to place, the numbers left are %v", playerID, value, game.Numbers[playerID])
+}
+
+// useNumber takes number out of the numbers playerID has left
+func useNumber(game *database.Game, playerID, number int) {
+
+	left := []int{}
+	for _, n := range game.Numbers[playerID] {
+		if n != number {
+			left = append(left, n)
+		}
+	}
+	game.Numbers[playerID] = left
+}
+
+// returnNumber gives number back to playerID when the move placing it is taken back
+func returnNumber(game *database.Game, playerID, number int) {
+
+	game.Numbers[playerID] = append(game.Numbers[playerID], number)
+	sort.Ints(game.Numbers[playerID])
+}
+
+// settleNumerical completes the game once the move at row, col completed a line adding up to 15, whoever's numbers it holds,
+// the board is full, the player to move has no number left to place, or no line can add up to 15 any more with the numbers left
+func settleNumerical(game *database.Game, row, col, playerID int) {
+
+	left := []int{}
+	for seat := range game.Players {
+		left = append(left, game.Numbers[seat]...)
+	}
+	sort.Ints(left)
+
+	if engine.IsNumericalWinningMove(game.GameBoard, row, col) {
+		winner := game.Players[playerID]
+		game.State = database.StateComplete
+		game.Winner = &winner
+	} else if engine.IsFull(game.GameBoard) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonBoardFull
+	} else if len(game.Numbers[game.NextPlayerIdx]) == 0 {
+		// nobody could move any more, the game would never end
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonNoWinnableLine
+	} else if !engine.NumericalCanStillBeWon(game.GameBoard, left) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonNoWinnableLine
+	}
+}
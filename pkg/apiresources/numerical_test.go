@@ -0,0 +1,109 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only numerical.go
+
+func TestPostAMoveNumerical(t *testing.T) {
+
+	stored := generateGameWithBoard(3, 3, 3)
+	stored.Variant = database.VariantNumerical
+	stored.Numbers = numericalNumbers()
+
+	storeGame(&stored)
+
+	// a move needs a value, and player 0 only places odd numbers
+	for _, body := range []string{`{"row": 1, "column": 1}`, `{"row": 1, "column": 1, "value": 2}`} {
+		w := httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", "0", body))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+
+	for i, body := range []string{`{"row": 1, "column": 1, "value": 5}`, `{"row": 0, "column": 0, "value": 4}`} {
+		w := httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", []string{"0", "1"}[i], body))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	assert.Equal(t, 5, stored.GameBoard[1][1])
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player2", Row: 0, Col: 0, Value: 4}, stored.Moves[1])
+	assert.Equal(t, map[int][]int{0: {1, 3, 7, 9}, 1: {2, 6, 8}}, stored.Numbers)
+
+	// every number is placed once
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 1, "value": 5}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 1, "value": 9}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 4 + 5 + 6 along the diagonal, the 5 being player 0's does not matter
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"row": 2, "column": 2, "value": 6}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player2", *stored.Winner)
+}
+
+func TestUndoNumericalMove(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.Variant = database.VariantNumerical
+	game.Numbers = numericalNumbers()
+
+	_, err := applyMoveWithMark(1, 1, 0, 5, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 7, 9}, game.Numbers[0])
+
+	// the 5 goes back to player 0, who is to move again
+	undoMoves(&game, 1)
+	assert.Equal(t, -1, game.GameBoard[1][1])
+	assert.Equal(t, 0, game.NextPlayerIdx)
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, game.Numbers[0])
+}
+
+func TestNumericalOddNumbersOpen(t *testing.T) {
+
+	stored := generateGameWithBoard(3, 3, 3)
+	stored.Variant = database.VariantNumerical
+	stored.Numbers = numericalNumbers()
+	assert.NoError(t, startGame(&stored))
+	assert.Equal(t, 0, stored.NextPlayerIdx)
+
+	storeGame(&stored)
+
+	// opening with an even number would leave player 1 short of a number before the board fills up
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"row": 0, "column": 0, "value": 2}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Empty(t, stored.Moves)
+}
+
+func TestSettleNumericalWithoutNumbersLeft(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.Variant = database.VariantNumerical
+
+	// the board left by a game player 1 opened: 2 3 4 / 7 6 9 / 8 5 _, with only player 0's 1 left and player 1 to move
+	game.GameBoard = [][]int{
+		{2, 3, 4},
+		{7, 6, 9},
+		{8, 5, -1},
+	}
+	game.Numbers = map[int][]int{0: {1}, 1: {}}
+	game.NextPlayerIdx = 1
+
+	settleNumerical(&game, 2, 1, 0)
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, database.DrawReasonNoWinnableLine, game.DrawReason)
+	assert.Nil(t, game.Winner)
+}
@@ -0,0 +1,464 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
+)
+
+const (
+	// gomokuSide is the number of rows and columns of the board games with an opening are played on
+	gomokuSide = 15
+
+	// gomokuWinLength is the number of stones in a row that win a game with an opening
+	gomokuWinLength = 5
+
+	// proDistance is how many rows or columns away from the center the second black stone of a pro game has to be
+	proDistance = 3
+
+	// swapStones is the number of stones player 0 places to open a swap or swap2 game
+	swapStones = 3
+
+	// swap2Stones is the number of stones player 1 may add in swap2 instead of choosing a color
+	swap2Stones = 2
+)
+
+// stoneColors are the colors of games with an opening, indexed by the mark stored on the GameBoard
+var stoneColors = []database.Color{database.ColorBlack, database.ColorWhite}
+
+/*
+	PlaceOpeningStones places the opening stones of a swap or swap2 game all at once
+	Player 0 opens with three stones, black, white and black, then player 1 chooses their color.
+	In swap2 player 1 may place a white and a black stone instead, and leave the choice of colors to player 0
+
+	POST /tictactoe/{game_id}/{player_id}/opening
+
+	Example Request
+		{
+			"stones" : [{"row": 7, "column": 7}, {"row": 7, "column": 8}, {"row": 9, "column": 9}]
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {
+				"moves": ["{gameId}/moves/0", "{gameId}/moves/1", "{gameId}/moves/2"]
+			}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest, the game has no swap opening, or the stones are too many, too few or on taken squares
+	  401 Unauthorized
+	  403 Forbidden
+	  404 NotFound
+	  409 Conflict, the opening is over, it is not the player's turn, a color has to be chosen, the player ran out of time
+	      or the game was updated by another request
+	  500 InternalServerError
+*/
+func PlaceOpeningStones(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type StoneRequest struct {
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	type OpeningRequest struct {
+		Stones []StoneRequest `json:"stones" validate:"required,min=1,max=3,dive"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	openingRequest := OpeningRequest{}
+	err = json.Unmarshal(requestBody, &openingRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(openingRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	stones := []database.Square{}
+	for _, s := range openingRequest.Stones {
+		stones = append(stones, database.Square{Row: *s.Row, Col: *s.Column})
+	}
+
+	moveNumbers := []int{}
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkOpeningTurn(r, game, playerID); e != nil {
+			return e
+		}
+
+		// the placement came too late, the player lost on time before making it
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		var e *statusError
+		moveNumbers, e = placeOpeningStones(game, playerID, stones)
+		return e
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "Player %d ran out of time, %s wins the game", playerID, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	moves := []string{}
+	for _, moveNumber := range moveNumbers {
+		moves = append(moves, fmt.Sprintf("%s/moves/%d", gameID, moveNumber))
+	}
+
+	response.Data = map[string]interface{}{
+		"moves": moves,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	ChooseColor picks the color the player plays for the rest of a swap or swap2 game, the opponent gets the other one
+	White places the next stone, and from then on the game is played one move at a time
+
+	POST /tictactoe/{game_id}/{player_id}/color
+
+	Example Request
+		{
+			"color" : "WHITE"
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {
+				"move": "{gameId}/moves/{move_number}",
+				"colors": {"0": "BLACK", "1": "WHITE"}
+			}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest, the game has no swap opening
+	  401 Unauthorized
+	  403 Forbidden
+	  404 NotFound
+	  409 Conflict, the opening is over, it is not the player's turn, stones have to be placed first, the player ran out
+	      of time or the game was updated by another request
+	  500 InternalServerError
+*/
+func ChooseColor(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type ColorRequest struct {
+		Color string `json:"color" validate:"required,oneof=BLACK WHITE"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	colorRequest := ColorRequest{}
+	err = json.Unmarshal(requestBody, &colorRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(colorRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	moveNumber := -1
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkOpeningTurn(r, game, playerID); e != nil {
+			return e
+		}
+
+		// the choice came too late, the player lost on time before making it
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		var e *statusError
+		moveNumber, e = chooseColor(game, playerID, database.Color(colorRequest.Color))
+		return e
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "Player %d ran out of time, %s wins the game", playerID, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"move":   fmt.Sprintf("%s/moves/%d", gameID, moveNumber),
+		"colors": game.Colors,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkOpeningTurn makes sure playerID may act on the swap opening of the game
+func checkOpeningTurn(r *http.Request, game *database.Game, playerID int) *statusError {
+
+	if game.State != database.StateInProgress {
+		return newStatusError(http.StatusConflict, "Game %s is %s, its opening is over", game.ID, game.State)
+	}
+
+	if _, ok := game.Players[playerID]; !ok {
+		return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+	}
+
+	if game.Opening != database.OpeningSwap && game.Opening != database.OpeningSwap2 {
+		return newStatusError(http.StatusBadRequest, "Only games with a swap or swap2 opening place stones together and choose colors")
+	}
+
+	if e := authorizeSeat(r, game, playerID); e != nil {
+		return e
+	}
+
+	if game.OpeningStage == "" {
+		return newStatusError(http.StatusConflict, "The opening of game %s is over", game.ID)
+	}
+
+	if game.NextPlayerIdx != playerID {
+		return newStatusError(http.StatusConflict, "It is not player %d's turn", playerID)
+	}
+
+	return nil
+}
+
+// placeOpeningStones places the three stones opening a swap game, or the two stones player 1 may add in swap2,
+// and hands the choice of colors to the opponent. Returns the moveNumber of every stone
+func placeOpeningStones(game *database.Game, playerID int, stones []database.Square) ([]int, *statusError) {
+
+	want := swapStones
+	if game.OpeningStage == database.OpeningStageChoose {
+		if game.Opening != database.OpeningSwap2 || countStones(game) != swapStones {
+			return nil, newStatusError(http.StatusConflict, "Player %d has to choose a color", playerID)
+		}
+		want = swap2Stones
+	}
+
+	if len(stones) != want {
+		return nil, newStatusError(http.StatusBadRequest, "Player %d places %d stones, not %d", playerID, want, len(stones))
+	}
+
+	moveNumbers := []int{}
+	for i, s := range stones {
+		// the stones alternate colors, counting back from the last one which is always black
+		mark := (want - 1 - i) % len(stoneColors)
+		moveNumber, err := playMove(s.Row, s.Col, playerID, mark, game)
+		if err != nil {
+			return nil, newStatusError(http.StatusBadRequest, "Failed to place the stone, it is illegal. %s", err.Error())
+		}
+		moveNumbers = append(moveNumbers, moveNumber)
+	}
+
+	// placing stones declines the opponent's draw offer
+	if game.DrawOfferedBy != nil && *game.DrawOfferedBy != playerID {
+		game.DrawOfferedBy = nil
+	}
+
+	game.OpeningStage = database.OpeningStageChoose
+	game.NextPlayerIdx = opponentOf(playerID)
+	pressClock(game, playerID)
+
+	return moveNumbers, nil
+}
+
+// chooseColor gives playerID the color they chose and the opponent the other one, ending the opening
+// White places the next stone. Returns the moveNumber of the COLOR move
+func chooseColor(game *database.Game, playerID int, color database.Color) (int, *statusError) {
+
+	if game.OpeningStage != database.OpeningStageChoose {
+		return -1, newStatusError(http.StatusConflict, "Player %d has to place the opening stones first", playerID)
+	}
+
+	other := database.ColorWhite
+	if color == database.ColorWhite {
+		other = database.ColorBlack
+	}
+
+	game.Colors = map[int]database.Color{
+		playerID:             color,
+		opponentOf(playerID): other,
+	}
+	game.Moves = append(game.Moves, database.Move{
+		Type:   database.MoveTypeColor,
+		Player: game.Players[playerID],
+		Color:  color,
+	})
+
+	game.OpeningStage = ""
+	game.NextPlayerIdx = markSeat(game, len(stoneColors)-1)
+	pressClock(game, playerID)
+
+	return len(game.Moves) - 1, nil
+}
+
+// checkProMove keeps the first stone of a pro game in the center, and the second black stone away from it
+func checkProMove(game *database.Game, row, col int) error {
+
+	center := game.Rows / 2
+	switch countStones(game) {
+	case 0:
+		if row != center || col != center {
+			return fmt.Errorf("the first stone has to be played in the center, row %d column %d", center, center)
+		}
+	case 2:
+		if row > center-proDistance && row < center+proDistance && col > center-proDistance && col < center+proDistance {
+			return fmt.Errorf("the second black stone has to be at least %d rows or columns away from the center", proDistance)
+		}
+	}
+
+	return nil
+}
+
+// countStones returns the number of stones placed so far
+func countStones(game *database.Game) int {
+
+	stones := 0
+	for _, move := range game.Moves {
+		if move.Type == database.MoveTypeMove {
+			stones++
+		}
+	}
+
+	return stones
+}
+
+// openingLength returns the number of moves of the opening, which can never be taken back
+// Only swap and swap2 openings count, and all moves played do until the colors are chosen.
+// Under the pie rule the first move counts once it was swapped, as the seats can not be traded back
+func openingLength(game *database.Game) int {
+
+	if game.OpeningStage != "" {
+		return len(game.Moves)
+	}
+
+	for i, move := range game.Moves {
+		if move.Type == database.MoveTypeColor || move.Type == database.MoveTypeSwap {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// playerMark returns the mark playerID places on the GameBoard, the color of their stones in a game with an opening
+func playerMark(game *database.Game, playerID int) int {
+
+	if color, ok := game.Colors[playerID]; ok {
+		for mark, c := range stoneColors {
+			if c == color {
+				return mark
+			}
+		}
+	}
+
+	return playerID
+}
+
+// markSeat returns the seat placing mark on the GameBoard, see playerMark
+func markSeat(game *database.Game, mark int) int {
+
+	for seat := range game.Colors {
+		if playerMark(game, seat) == mark {
+			return seat
+		}
+	}
+
+	return mark
+}
+
+// validateOpening makes sure a game with an opening rule is a two player game of five in a row on a 15x15 board
+func validateOpening(opening database.OpeningRule, variant database.Variant, ruleSet database.RuleSet, players, rows, columns, winLength int, gravity, bot bool) (bool, string) {
+
+	if opening == "" {
+		return true, ""
+	}
+
+	if rows != gomokuSide || columns != gomokuSide || winLength != gomokuWinLength {
+		return false, fmt.Sprintf("the %s opening is played on a %dx%d board with %d in a row", opening, gomokuSide, gomokuSide, gomokuWinLength)
+	}
+	if variant != database.VariantClassic || ruleSet != database.RuleSetStandard || gravity {
+		return false, fmt.Sprintf("the %s opening is played on a classic board with the standard rules", opening)
+	}
+	if players > playersPerGame {
+		return false, fmt.Sprintf("the %s opening is played by two players", opening)
+	}
+	if bot {
+		return false, fmt.Sprintf("the computer does not play the %s opening", opening)
+	}
+
+	return true, ""
+}
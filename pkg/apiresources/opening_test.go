@@ -0,0 +1,129 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only opening.go
+
+func TestSwap2Opening(t *testing.T) {
+
+	stored := generateGameWithBoard(15, 15, 5)
+	stored.Opening = database.OpeningSwap2
+	stored.OpeningStage = database.OpeningStagePlace
+	stored.NextPlayerIdx = 0
+
+	storeGame(&stored)
+
+	// single moves wait for the opening to be over, and player 0 places three stones before anything else
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 7, "column": 7}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	ChooseColor(w, newActionRequest(http.MethodPost, "0", "color", `{"color": "BLACK"}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	PlaceOpeningStones(w, newActionRequest(http.MethodPost, "1", "opening", `{"stones": [{"row": 7, "column": 7}, {"row": 7, "column": 8}, {"row": 9, "column": 9}]}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	PlaceOpeningStones(w, newActionRequest(http.MethodPost, "0", "opening", `{"stones": [{"row": 7, "column": 7}, {"row": 7, "column": 8}]}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PlaceOpeningStones(w, newActionRequest(http.MethodPost, "0", "opening", `{"stones": [{"row": 7, "column": 7}, {"row": 7, "column": 8}, {"row": 9, "column": 9}]}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, 0, stored.GameBoard[7][7])
+	assert.Equal(t, 1, stored.GameBoard[7][8])
+	assert.Equal(t, 0, stored.GameBoard[9][9])
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player1", Row: 7, Col: 8, Color: database.ColorWhite}, stored.Moves[1])
+	assert.Equal(t, database.OpeningStageChoose, stored.OpeningStage)
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	// player 1 adds a white and a black stone, leaving the choice to player 0
+	w = httptest.NewRecorder()
+	PlaceOpeningStones(w, newActionRequest(http.MethodPost, "1", "opening", `{"stones": [{"row": 8, "column": 8}, {"row": 6, "column": 6}]}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, stored.GameBoard[8][8])
+	assert.Equal(t, 0, stored.GameBoard[6][6])
+	assert.Equal(t, 0, stored.NextPlayerIdx)
+
+	w = httptest.NewRecorder()
+	PlaceOpeningStones(w, newActionRequest(http.MethodPost, "0", "opening", `{"stones": [{"row": 0, "column": 0}, {"row": 0, "column": 1}]}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	ChooseColor(w, newActionRequest(http.MethodPost, "0", "color", `{"color": "WHITE"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, map[int]database.Color{0: database.ColorWhite, 1: database.ColorBlack}, stored.Colors)
+	assert.Equal(t, database.Move{Type: database.MoveTypeColor, Player: "player1", Color: database.ColorWhite}, stored.Moves[5])
+	assert.Empty(t, stored.OpeningStage)
+
+	// white moves next, and player 0 now places white stones
+	assert.Equal(t, 0, stored.NextPlayerIdx)
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 10, "column": 10}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, stored.GameBoard[10][10])
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	// the opening can not be taken back
+	w = httptest.NewRecorder()
+	RequestTakeback(w, newActionRequest(http.MethodPost, "0", "takeback", `{"moves": 2}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSwappedColorsWin(t *testing.T) {
+
+	game := generateGameWithBoard(15, 15, 5)
+	game.Opening = database.OpeningSwap
+	game.Colors = map[int]database.Color{0: database.ColorWhite, 1: database.ColorBlack}
+	for col := 0; col < 4; col++ {
+		game.GameBoard[0][col] = 0
+	}
+
+	_, err := applyMove(14, 14, 0, &game)
+	assert.NoError(t, err)
+
+	// player 1 plays black, so the four black stones are theirs to complete
+	_, err = applyMove(0, 4, 1, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, "player2", *game.Winner)
+
+	game.State = database.StateInProgress
+	game.Winner = nil
+	undoMoves(&game, 1)
+	assert.Equal(t, 1, game.NextPlayerIdx)
+}
+
+func TestProOpening(t *testing.T) {
+
+	game := generateGameWithBoard(15, 15, 5)
+	game.Opening = database.OpeningPro
+	game.Colors = map[int]database.Color{0: database.ColorBlack, 1: database.ColorWhite}
+
+	// black opens in the center
+	_, err := applyMove(0, 0, 0, &game)
+	assert.Error(t, err)
+	_, err = applyMove(7, 7, 0, &game)
+	assert.NoError(t, err)
+	_, err = applyMove(7, 8, 1, &game)
+	assert.NoError(t, err)
+
+	// and plays its second stone outside the 5x5 square around it
+	_, err = applyMove(9, 9, 0, &game)
+	assert.Error(t, err)
+	_, err = applyMove(7, 10, 0, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, database.ColorBlack, game.Moves[2].Color)
+}
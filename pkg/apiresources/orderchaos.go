@@ -0,0 +1,54 @@
+package apiresources
+
+import (
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+const (
+	// orderAndChaosSide is the number of rows and columns of an order-chaos board
+	orderAndChaosSide = 6
+
+	// orderAndChaosWinLength is the number of symbols in a row Order needs
+	orderAndChaosWinLength = 5
+)
+
+// settleOrderAndChaos completes the game for Order once the move at row, col made exactly five in a row, whoever played it,
+// and for Chaos once the board is full or no line of exactly five can be made any more. Six in a row does not count
+func settleOrderAndChaos(game *database.Game, row, col int) {
+
+	role := database.RoleChaos
+	if engine.IsOrderWinningMove(game.GameBoard, row, col, game.WinLength) {
+		role = database.RoleOrder
+	} else if !engine.IsFull(game.GameBoard) && engine.OrderCanStillBeWon(game.GameBoard, game.WinLength) {
+		return
+	}
+
+	for seat, r := range game.Roles {
+		if r == role {
+			winner := game.Players[seat]
+			game.State = database.StateComplete
+			game.Winner = &winner
+		}
+	}
+}
+
+// orderAndChaosRoles hands Order to orderSeat and Chaos to the other seat
+func orderAndChaosRoles(orderSeat int) map[int]database.Role {
+	return map[int]database.Role{
+		orderSeat:             database.RoleOrder,
+		opponentOf(orderSeat): database.RoleChaos,
+	}
+}
+
+// orderSeat returns the seat playing Order, and false for games of any other variant
+func orderSeat(game *database.Game) (int, bool) {
+
+	for seat, role := range game.Roles {
+		if role == database.RoleOrder {
+			return seat, true
+		}
+	}
+
+	return -1, false
+}
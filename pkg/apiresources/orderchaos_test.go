@@ -0,0 +1,101 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only orderchaos.go
+
+func TestOrderWinsWhoeverMakesFive(t *testing.T) {
+
+	// player2 plays Order and opens the game
+	stored := generateOrderAndChaosGame(1)
+	assert.NoError(t, startGame(&stored))
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	storeGame(&stored)
+
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 0, "symbol": "X"}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// both players put Os along the top row, and Chaos carelessly adds the fifth
+	for col := 0; col < 4; col++ {
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", []string{"1", "0"}[col%2], fmt.Sprintf(`{"row": 0, "column": %d, "symbol": "O"}`, col)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"row": 5, "column": 5, "symbol": "X"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 4, "symbol": "O"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player2", *stored.Winner)
+	assert.Equal(t, database.SymbolO, stored.Moves[5].Symbol)
+}
+
+func TestChaosWinsOnceNoFiveCanBeMade(t *testing.T) {
+
+	game := generateOrderAndChaosGame(0)
+	game.NextPlayerIdx = 1
+	game.GameBoard = [][]int{
+		{-1, 1, 0, 0, 0, 1},
+		{0, 0, 0, 1, 1, 1},
+		{0, 1, 0, 1, 1, 1},
+		{1, 1, 1, 0, 0, 0},
+		{1, 0, 1, 0, 1, 0},
+		{0, 1, 1, 1, 0, -1},
+	}
+
+	// every line of five holds both symbols once the corner is taken, so the last square can not help Order
+	_, err := applyMoveWithMark(0, 0, 1, 0, &game)
+	assert.NoError(t, err)
+
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, "player2", *game.Winner)
+	assert.Empty(t, game.DrawReason)
+}
+
+func TestOrderOverlineDoesNotWin(t *testing.T) {
+
+	game := generateOrderAndChaosGame(0)
+	game.NextPlayerIdx = 1
+	game.GameBoard[0] = []int{1, 1, -1, 1, 1, 1}
+
+	// filling the gap makes six Os in a row, which is no win for Order
+	_, err := applyMoveWithMark(0, 2, 1, 1, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, database.StateInProgress, game.State)
+	assert.Nil(t, game.Winner)
+
+	// exactly five down the first column is
+	for row := 1; row < 4; row++ {
+		game.GameBoard[row][0] = 1
+	}
+	game.GameBoard[5][0] = 0
+	_, err = applyMoveWithMark(4, 0, 0, 1, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, "player1", *game.Winner)
+}
+
+// generateOrderAndChaosGame returns an order-chaos game with Order played by orderSeat
+func generateOrderAndChaosGame(orderSeat int) database.Game {
+
+	game := generateGameWithBoard(6, 6, 5)
+	game.Variant = database.VariantOrderAndChaos
+	game.Roles = orderAndChaosRoles(orderSeat)
+
+	return game
+}
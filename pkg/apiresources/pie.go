@@ -0,0 +1,174 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+)
+
+/*
+	SwapSides lets the second player of a game played by the pie rule take over the first move instead of answering it
+	The players trade seats, so the player swapping now holds the seat that made the first move, along with its mark,
+	and the first player moves again from the other seat. Both keep their own tokens and clocks, but act for the seat
+	they moved to from now on. The response returns the new player_id of the player swapping
+
+	POST /tictactoe/{game_id}/{player_id}/swap
+
+	Example Response
+		{
+			"error": null,
+			"data": {
+				"move": "{gameId}/moves/1",
+				"playerId": 0
+			}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest, the game is not played by the pie rule
+	  401 Unauthorized
+	  403 Forbidden
+	  404 NotFound
+	  409 Conflict, it is not the player's turn, the first move was not the only move played, the player ran out of time
+	      or the game was updated by another request
+	  500 InternalServerError
+*/
+func SwapSides(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	moveNumber := -1
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateInProgress {
+			return newStatusError(http.StatusConflict, "Game %s is %s, sides can not be swapped", game.ID, game.State)
+		}
+
+		if _, ok := game.Players[playerID]; !ok {
+			return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+		}
+
+		if !game.PieRule {
+			return newStatusError(http.StatusBadRequest, "Game %s is not played by the pie rule", game.ID)
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		if game.NextPlayerIdx != playerID {
+			return newStatusError(http.StatusConflict, "It is not player %d's turn", playerID)
+		}
+
+		if len(game.Moves) != 1 || game.Moves[0].Type != database.MoveTypeMove {
+			return newStatusError(http.StatusConflict, "Sides can only be swapped in answer to the first move")
+		}
+
+		// the swap came too late, the player lost on time before making it
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		moveNumber = swapSides(game, playerID)
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "Player %d ran out of time, %s wins the game", playerID, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"move":     fmt.Sprintf("%s/moves/%d", gameID, moveNumber),
+		"playerId": opponentOf(playerID),
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// swapSides records the SWAP of playerID and trades the seats of both players. Returns the moveNumber of the SWAP move
+// Unlike a move, a swap leaves NextPlayerIdx alone: the seat to move stays the same, it is the player holding it that changed
+func swapSides(game *database.Game, playerID int) int {
+
+	game.Moves = append(game.Moves, database.Move{
+		Type:   database.MoveTypeSwap,
+		Player: game.Players[playerID],
+	})
+
+	// swapping answers a takeback with a no, and declines the opponent's draw offer like a move does
+	game.PendingTakeback = nil
+	if game.DrawOfferedBy != nil && *game.DrawOfferedBy != playerID {
+		game.DrawOfferedBy = nil
+	}
+
+	// the time spent deciding is charged to playerID before their clock moves seats along with them
+	pressClock(game, playerID)
+	swapSeats(game)
+
+	return len(game.Moves) - 1
+}
+
+// swapSeats trades the seats of the two players, with everything that belongs to the player rather than the seat
+func swapSeats(game *database.Game) {
+
+	game.Players[0], game.Players[1] = game.Players[1], game.Players[0]
+	if game.TokenHashes != nil {
+		game.TokenHashes[0], game.TokenHashes[1] = game.TokenHashes[1], game.TokenHashes[0]
+	}
+	if game.Clocks != nil {
+		game.Clocks[0], game.Clocks[1] = game.Clocks[1], game.Clocks[0]
+	}
+	if game.DrawOfferedBy != nil {
+		seat := opponentOf(*game.DrawOfferedBy)
+		game.DrawOfferedBy = &seat
+	}
+}
+
+// validatePieRule makes sure a game played by the pie rule gives each of its two players their own mark
+func validatePieRule(pieRule bool, variant database.Variant, ruleSet database.RuleSet, opening database.OpeningRule, players int, bot bool) (bool, string) {
+
+	if !pieRule {
+		return true, ""
+	}
+
+	switch variant {
+	case database.VariantClassic, database.VariantUltimate, database.VariantQubic:
+	default:
+		return false, fmt.Sprintf("the pie rule is not played in %s games", variant)
+	}
+	if ruleSet == database.RuleSetNotakto {
+		return false, "the pie rule is not played in notakto, both players place the same mark"
+	}
+	if opening != "" {
+		return false, fmt.Sprintf("the pie rule can not be added to the %s opening", opening)
+	}
+	if players > playersPerGame {
+		return false, "the pie rule is played by two players"
+	}
+	if bot {
+		return false, "the computer does not play by the pie rule"
+	}
+
+	return true, ""
+}
@@ -0,0 +1,105 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// A test file for only pie.go
+
+func TestSwapSides(t *testing.T) {
+
+	stored := generateGameWithBoard(3, 3, 3)
+	stored.PieRule = true
+
+	storeGame(&stored)
+
+	// there is nothing to swap before the first move
+	w := httptest.NewRecorder()
+	SwapSides(w, newActionRequest(http.MethodPost, "1", "swap", ""))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// only the second player may swap
+	w = httptest.NewRecorder()
+	SwapSides(w, newActionRequest(http.MethodPost, "0", "swap", ""))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	SwapSides(w, newActionRequest(http.MethodPost, "1", "swap", ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// player2 took over the center, and player1 moves again from the other seat
+	assert.Equal(t, map[int]string{0: "player2", 1: "player1"}, stored.Players)
+	assert.Equal(t, map[int]string{0: hashSeatToken("token1"), 1: hashSeatToken("token0")}, stored.TokenHashes)
+	assert.Equal(t, database.Move{Type: database.MoveTypeSwap, Player: "player2"}, stored.Moves[1])
+	assert.Equal(t, 0, stored.GameBoard[1][1])
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	// player1's token now acts for seat 1, and player2's no longer does
+	w = httptest.NewRecorder()
+	SwapSides(w, newActionRequest(http.MethodPost, "1", "swap", ""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the seats only trade once, and the swap stays
+	r := newActionRequest(http.MethodPost, "1", "swap", "")
+	r.Header.Set("Authorization", "Bearer token0")
+	w = httptest.NewRecorder()
+	SwapSides(w, r)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	r = newActionRequest(http.MethodPost, "1", "takeback", `{"moves": 2}`)
+	r.Header.Set("Authorization", "Bearer token0")
+	w = httptest.NewRecorder()
+	RequestTakeback(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSwapSidesWithoutPieRule(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.GameBoard[1][1] = 0
+	game.Moves = []database.Move{{Type: database.MoveTypeMove, Player: "player1", Row: 1, Col: 1}}
+	game.NextPlayerIdx = 1
+
+	dbMock.On("GetGameWithID", "gameID1").Return(game, nil)
+
+	w := httptest.NewRecorder()
+	SwapSides(w, newActionRequest(http.MethodPost, "1", "swap", ""))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	dbMock.AssertNotCalled(t, "CompareAndSwapGame", mock.Anything)
+}
+
+func TestValidatePieRule(t *testing.T) {
+
+	ok, _ := validatePieRule(true, database.VariantUltimate, database.RuleSetStandard, "", 2, false)
+	assert.True(t, ok)
+
+	ok, _ = validatePieRule(true, database.VariantWild, database.RuleSetStandard, "", 2, false)
+	assert.False(t, ok)
+
+	ok, _ = validatePieRule(true, database.VariantClassic, database.RuleSetNotakto, "", 2, false)
+	assert.False(t, ok)
+
+	ok, _ = validatePieRule(true, database.VariantClassic, database.RuleSetStandard, database.OpeningSwap, 2, false)
+	assert.False(t, ok)
+
+	ok, _ = validatePieRule(true, database.VariantClassic, database.RuleSetStandard, "", 3, false)
+	assert.False(t, ok)
+
+	ok, _ = validatePieRule(true, database.VariantClassic, database.RuleSetStandard, "", 2, true)
+	assert.False(t, ok)
+}
@@ -0,0 +1,340 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
+)
+
+/*
+	CollapseMark decides which of its two squares the spooky mark that closed a cycle collapses into
+	Only the opponent of the player who closed the cycle may decide, and they play their own move after it.
+	Every other mark tied to the cycle collapses along with it, and the game completes once a line of classical marks
+	is made or the board is full
+
+	POST /tictactoe/{game_id}/{player_id}/collapse
+
+	Example Request
+		{
+			"row" : 1,
+			"column" : 1
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data" : {
+				"move": "{gameId}/moves/{move_number}"
+				"winner": "player1" // omitempty
+				"scores": {"0": 1, "1": 0.5} // omitempty, the points of every player who completed a line
+				"drawReason": "BOARD_FULL" // omitempty
+			}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest, the game is not quantum or the square is not one of the mark's
+	  401 Unauthorized
+	  403 Forbidden, the player closed the cycle themselves
+	  404 NotFound
+	  409 Conflict, no collapse is awaited, the player ran out of time or the game was updated by another request
+	  500 InternalServerError
+*/
+func CollapseMark(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type CollapseRequest struct {
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	collapseRequest := CollapseRequest{}
+	err = json.Unmarshal(requestBody, &collapseRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(collapseRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	moveNumber := -1
+	outOfTime := false
+	game, e := updateGame(gameID, func(game *database.Game) *statusError {
+
+		if game.State != database.StateInProgress {
+			return newStatusError(http.StatusConflict, "Game %s is %s, no mark can collapse", game.ID, game.State)
+		}
+
+		if _, ok := game.Players[playerID]; !ok {
+			return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+		}
+
+		if game.Variant != database.VariantQuantum {
+			return newStatusError(http.StatusBadRequest, "Only the marks of quantum games collapse")
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		if game.Quantum.PendingCollapse == nil {
+			return newStatusError(http.StatusConflict, "No cycle is waiting to collapse in game %s", game.ID)
+		}
+
+		if game.NextPlayerIdx != playerID {
+			return newStatusError(http.StatusForbidden, "Player %d closed the cycle, their opponent decides how it collapses", playerID)
+		}
+
+		// the decision came too late, the player lost on time before making it
+		if flagFell(game, now()) {
+			flagGame(game)
+			outOfTime = true
+			return nil
+		}
+
+		var err error
+		moveNumber, err = collapseQuantum(game, playerID, database.Square{Row: *collapseRequest.Row, Col: *collapseRequest.Column})
+		if err != nil {
+			return newStatusError(http.StatusBadRequest, "Failed to collapse the mark. %s", err.Error())
+		}
+
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	if outOfTime {
+		e = newStatusError(http.StatusConflict, "Player %d ran out of time, %s wins the game", playerID, *game.Winner)
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"move": fmt.Sprintf("%s/moves/%d", gameID, moveNumber),
+	}
+	if game.Winner != nil {
+		response.Data["winner"] = *game.Winner
+	}
+	if len(game.Quantum.Scores) > 0 {
+		response.Data["scores"] = game.Quantum.Scores
+	}
+	if game.DrawReason != "" {
+		response.Data["drawReason"] = game.DrawReason
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// postQuantumMove places the spooky mark of playerID in the squares of the request body
+func postQuantumMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type SquareRequest struct {
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	type QuantumMoveRequest struct {
+		Squares []SquareRequest `json:"squares" validate:"required,max=2,dive"`
+	}
+
+	moveRequest := QuantumMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "the squares of its spooky mark"); e != nil {
+		return -1, e
+	}
+
+	squares := []database.Square{}
+	for _, s := range moveRequest.Squares {
+		squares = append(squares, database.Square{Row: *s.Row, Col: *s.Column})
+	}
+
+	return playQuantumMove(game, playerID, squares)
+}
+
+// playQuantumMove places the spooky mark of playerID in both squares, and hands the turn to the opponent
+// When a single square is left the mark is played into it, classical from the start. Returns the moveNumber
+func playQuantumMove(game *database.Game, playerID int, squares []database.Square) (int, *statusError) {
+
+	if game.Quantum.PendingCollapse != nil {
+		return -1, newStatusError(http.StatusConflict, "Mark %d closed a cycle, player %d has to collapse it first", *game.Quantum.PendingCollapse, game.NextPlayerIdx)
+	}
+
+	open := 0
+	for _, row := range game.GameBoard {
+		for _, square := range row {
+			if square == engine.Empty {
+				open++
+			}
+		}
+	}
+
+	if open == 1 && len(squares) != 1 {
+		return -1, newStatusError(http.StatusBadRequest, "A single square is left, the move takes only that square")
+	}
+	if open > 1 && (len(squares) != 2 || squares[0] == squares[1]) {
+		return -1, newStatusError(http.StatusBadRequest, "Game %s is quantum, a move takes two different squares", game.ID)
+	}
+
+	for _, s := range squares {
+		if s.Row >= game.Rows || s.Col >= game.Columns {
+			return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. row %d column %d is off the %dx%d board", s.Row, s.Col, game.Rows, game.Columns)
+		}
+		if game.GameBoard[s.Row][s.Col] != engine.Empty {
+			return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. row %d column %d already holds a classical mark", s.Row, s.Col)
+		}
+	}
+
+	mark := database.SpookyMark{Player: playerID, Squares: [2]database.Square{squares[0], squares[len(squares)-1]}}
+	move := database.Move{
+		Type:   database.MoveTypeMove,
+		Player: game.Players[playerID],
+	}
+	if len(squares) == 1 {
+		mark.Collapsed = &squares[0]
+		move.Row = squares[0].Row
+		move.Col = squares[0].Col
+		game.GameBoard[squares[0].Row][squares[0].Col] = playerID
+	} else {
+		move.Squares = squares
+	}
+
+	game.Quantum.Marks = append(game.Quantum.Marks, mark)
+	move.Mark = len(game.Quantum.Marks)
+	game.Moves = append(game.Moves, move)
+
+	// moving on declines the opponent's draw offer
+	if game.DrawOfferedBy != nil && *game.DrawOfferedBy != playerID {
+		game.DrawOfferedBy = nil
+	}
+
+	game.NextPlayerIdx = nextSeat(game, playerID)
+
+	if mark.Collapsed != nil {
+		settleQuantum(game)
+	} else if marks, _ := quantumMarks(game); engine.QuantumClosesCycle(marks) {
+		// the opponent now decides how the cycle collapses, before playing their own move
+		game.Quantum.PendingCollapse = &move.Mark
+	}
+
+	pressClock(game, playerID)
+
+	return len(game.Moves) - 1, nil
+}
+
+// collapseQuantum collapses the mark closing the cycle into square, along with every mark tied to it, as decided by playerID
+// The turn stays with playerID, who plays their own move next. Returns the moveNumber of the COLLAPSE move
+func collapseQuantum(game *database.Game, playerID int, square database.Square) (int, error) {
+
+	marks, indexes := quantumMarks(game)
+
+	// no move is played while a collapse is awaited, so the mark closing the cycle is the last one
+	collapsed, err := engine.QuantumCollapse(marks, len(marks)-1, engine.Square{Row: square.Row, Column: square.Col})
+	if err != nil {
+		return -1, err
+	}
+
+	for i, s := range collapsed {
+		mark := &game.Quantum.Marks[indexes[i]]
+		mark.Collapsed = &database.Square{Row: s.Row, Col: s.Column}
+		game.GameBoard[s.Row][s.Column] = mark.Player
+	}
+
+	game.Moves = append(game.Moves, database.Move{
+		Type:   database.MoveTypeCollapse,
+		Player: game.Players[playerID],
+		Row:    square.Row,
+		Col:    square.Col,
+		Mark:   *game.Quantum.PendingCollapse,
+	})
+	game.Quantum.PendingCollapse = nil
+
+	settleQuantum(game)
+	if game.State != database.StateInProgress {
+		stopClock(game, now())
+	}
+
+	return len(game.Moves) - 1, nil
+}
+
+// settleQuantum completes the game once the classical marks complete a line, scoring every player owning one,
+// or once the board is full
+func settleQuantum(game *database.Game) {
+
+	numbers := emptyBoard(game.Rows, game.Columns)
+	for i, mark := range game.Quantum.Marks {
+		if mark.Collapsed != nil {
+			numbers[mark.Collapsed.Row][mark.Collapsed.Col] = i + 1
+		}
+	}
+
+	scores := engine.QuantumScores(game.GameBoard, numbers)
+	if len(scores) > 0 {
+		game.State = database.StateComplete
+		game.Quantum.Scores = scores
+		for seat, points := range scores {
+			if points == 1 {
+				winner := game.Players[seat]
+				game.Winner = &winner
+			}
+		}
+		return
+	}
+
+	if engine.IsFull(game.GameBoard) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonBoardFull
+	}
+}
+
+// quantumMarks returns the marks still in superposition for the engine, along with the index into Quantum.Marks of each
+func quantumMarks(game *database.Game) ([]engine.SpookyMark, []int) {
+
+	marks := []engine.SpookyMark{}
+	indexes := []int{}
+	for i, mark := range game.Quantum.Marks {
+		if mark.Collapsed != nil {
+			continue
+		}
+
+		marks = append(marks, engine.SpookyMark{Squares: [2]engine.Square{
+			{Row: mark.Squares[0].Row, Column: mark.Squares[0].Col},
+			{Row: mark.Squares[1].Row, Column: mark.Squares[1].Col},
+		}})
+		indexes = append(indexes, i)
+	}
+
+	return marks, indexes
+}
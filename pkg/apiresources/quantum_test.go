@@ -0,0 +1,148 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only quantum.go
+
+func TestQuantumCycleCollapses(t *testing.T) {
+
+	stored := generateQuantumGame()
+
+	storeGame(&stored)
+
+	// a spooky mark takes two different squares
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"squares": [{"row": 0, "column": 0}, {"row": 0, "column": 0}]}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	for i, body := range []string{
+		`{"squares": [{"row": 0, "column": 0}, {"row": 0, "column": 1}]}`,
+		`{"squares": [{"row": 0, "column": 1}, {"row": 1, "column": 1}]}`,
+	} {
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", []string{"0", "1"}[i], body))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, database.Move{
+		Type:    database.MoveTypeMove,
+		Player:  "player2",
+		Squares: []database.Square{{Row: 0, Col: 1}, {Row: 1, Col: 1}},
+		Mark:    2,
+	}, stored.Moves[1])
+
+	// nothing to collapse yet
+	w = httptest.NewRecorder()
+	CollapseMark(w, newActionRequest(http.MethodPost, "0", "collapse", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// the third mark closes a cycle through the corner, the edge and the center
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"squares": [{"row": 1, "column": 1}, {"row": 0, "column": 0}]}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, *stored.Quantum.PendingCollapse)
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	// player 1 has to decide the collapse before moving, and only they may decide it
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"squares": [{"row": 2, "column": 0}, {"row": 2, "column": 1}]}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	CollapseMark(w, newActionRequest(http.MethodPost, "0", "collapse", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	CollapseMark(w, newActionRequest(http.MethodPost, "1", "collapse", `{"row": 2, "column": 2}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	CollapseMark(w, newActionRequest(http.MethodPost, "1", "collapse", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, stored.Quantum.PendingCollapse)
+	assert.Equal(t, [][]int{{0, 0, -1}, {-1, 1, -1}, {-1, -1, -1}}, stored.GameBoard)
+	assert.Equal(t, database.Square{Row: 0, Col: 1}, *stored.Quantum.Marks[0].Collapsed)
+	assert.Equal(t, database.Move{Type: database.MoveTypeCollapse, Player: "player2", Row: 0, Col: 0, Mark: 3}, stored.Moves[3])
+	assert.Equal(t, database.StateInProgress, stored.State)
+
+	// the turn stays with the player who decided the collapse
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"squares": [{"row": 2, "column": 0}, {"row": 2, "column": 1}]}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestQuantumSimultaneousLines(t *testing.T) {
+
+	game := generateQuantumGame()
+
+	// both players have two classical marks along a row, and their last marks join the two squares left in those rows
+	game.GameBoard = [][]int{
+		{0, 0, -1},
+		{-1, -1, -1},
+		{1, 1, -1},
+	}
+	for _, mark := range []database.SpookyMark{
+		{Player: 0, Squares: [2]database.Square{{Row: 0, Col: 0}, {Row: 1, Col: 0}}, Collapsed: &database.Square{Row: 0, Col: 0}},
+		{Player: 1, Squares: [2]database.Square{{Row: 2, Col: 0}, {Row: 1, Col: 0}}, Collapsed: &database.Square{Row: 2, Col: 0}},
+		{Player: 0, Squares: [2]database.Square{{Row: 0, Col: 1}, {Row: 1, Col: 1}}, Collapsed: &database.Square{Row: 0, Col: 1}},
+		{Player: 1, Squares: [2]database.Square{{Row: 2, Col: 1}, {Row: 1, Col: 1}}, Collapsed: &database.Square{Row: 2, Col: 1}},
+	} {
+		game.Quantum.Marks = append(game.Quantum.Marks, mark)
+	}
+
+	_, err := playQuantumMove(&game, 0, []database.Square{{Row: 0, Col: 2}, {Row: 2, Col: 2}})
+	assert.Nil(t, err)
+	_, err = playQuantumMove(&game, 1, []database.Square{{Row: 2, Col: 2}, {Row: 0, Col: 2}})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, *game.Quantum.PendingCollapse)
+
+	// both rows complete at once, the top row's highest subscript 5 is lower than the bottom row's 6
+	_, e := collapseQuantum(&game, 0, database.Square{Row: 2, Col: 2})
+	assert.NoError(t, e)
+
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, "player1", *game.Winner)
+	assert.Equal(t, map[int]float64{0: 1, 1: 0.5}, game.Quantum.Scores)
+}
+
+func TestQuantumLastSquare(t *testing.T) {
+
+	game := generateQuantumGame()
+	game.GameBoard = [][]int{
+		{0, 1, 0},
+		{0, 1, 1},
+		{1, 0, -1},
+	}
+
+	// a spooky mark needs two squares, so the last one left takes a classical mark
+	_, err := playQuantumMove(&game, 0, []database.Square{{Row: 2, Col: 2}, {Row: 1, Col: 1}})
+	assert.Equal(t, http.StatusBadRequest, err.status)
+
+	_, err = playQuantumMove(&game, 0, []database.Square{{Row: 2, Col: 2}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player1", Row: 2, Col: 2, Mark: 1}, game.Moves[0])
+	assert.Equal(t, database.StateComplete, game.State)
+	assert.Equal(t, database.DrawReasonBoardFull, game.DrawReason)
+	assert.Nil(t, game.Winner)
+}
+
+func generateQuantumGame() database.Game {
+	game := generateGameWithBoard(3, 3, 3)
+	game.Variant = database.VariantQuantum
+	game.Quantum = &database.Quantum{Marks: []database.SpookyMark{}}
+
+	return game
+}
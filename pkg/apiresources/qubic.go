@@ -0,0 +1,50 @@
+package apiresources
+
+import (
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+// postQubicMove plays the layer, row and column of the request body for playerID
+// The layers are stacked into the GameBoard, so the row played is the row of the layer within it
+func postQubicMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type QubicMoveRequest struct {
+		Layer  *int `json:"layer" validate:"required,gte=0"`
+		Row    *int `json:"row" validate:"required,gte=0"`
+		Column *int `json:"column" validate:"required,gte=0"`
+	}
+
+	moveRequest := QubicMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "a layer, a row and a column"); e != nil {
+		return -1, e
+	}
+
+	if *moveRequest.Layer >= game.Layers {
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. layer provided (%d) is out of range [0-%d]", *moveRequest.Layer, game.Layers-1)
+	}
+	if *moveRequest.Row >= game.Rows {
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. row provided (%d) is out of range [0-%d]", *moveRequest.Row, game.Rows-1)
+	}
+
+	row := engine.QubicBoardRow(*moveRequest.Layer, *moveRequest.Row)
+	return placeMark(game, playerID, row, *moveRequest.Column, playerMark(game, playerID))
+}
+
+// settleQubic completes the game once a line of the cube is complete, the cube is full, or no line can be completed any more
+func settleQubic(game *database.Game) {
+
+	if mark := engine.QubicWinner(game.GameBoard); mark != engine.Empty {
+		winner := game.Players[mark]
+		game.State = database.StateComplete
+		game.Winner = &winner
+	} else if engine.IsFull(game.GameBoard) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonBoardFull
+	} else if !engine.QubicCanStillBeWon(game.GameBoard, game.NextPlayerIdx) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonNoWinnableLine
+	}
+}
@@ -0,0 +1,73 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only qubic.go
+
+func TestPostAMoveQubic(t *testing.T) {
+
+	stored := generateGameWithBoard(16, 4, 4)
+	stored.Rows = 4
+	stored.Layers = 4
+	stored.Variant = database.VariantQubic
+
+	storeGame(&stored)
+
+	// a move needs all three coordinates
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 1, "column": 1}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"layer": 0, "row": 4, "column": 1}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// player 0 plays down the space diagonal while player 1 plays along the top row of the first layer
+	for i := 0; i < 3; i++ {
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", "0", fmt.Sprintf(`{"layer": %d, "row": %d, "column": %d}`, i, i, i)))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", "1", fmt.Sprintf(`{"layer": 0, "row": 0, "column": %d}`, i+1)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	layer := 2
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player1", Row: 2, Col: 2, Layer: &layer}, stored.Moves[4])
+	assert.Equal(t, 0, stored.GameBoard[10][2])
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"layer": 3, "row": 3, "column": 3}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+}
+
+func TestUndoQubicMove(t *testing.T) {
+
+	game := generateGameWithBoard(16, 4, 4)
+	game.Rows = 4
+	game.Layers = 4
+	game.Variant = database.VariantQubic
+
+	// row 6 of the stacked board is row 2 of layer 1
+	_, err := applyMove(6, 2, 0, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *game.Moves[0].Layer)
+	assert.Equal(t, 2, game.Moves[0].Row)
+
+	// with the only move gone either player may open again
+	undoMoves(&game, 1)
+	assert.Equal(t, -1, game.GameBoard[6][2])
+	assert.Equal(t, -1, game.NextPlayerIdx)
+}
@@ -5,28 +5,49 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/rs/cors"
 	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/events"
 )
 
 // make this package variable an interface to enable mocked testing
 var dbClient database.DB
 
-// GetRouter builds the main router with the tictactoe subrouter
-func GetRouter() *mux.Router {
+// gameEvents is fed by every game update and read by the live endpoints
+var gameEvents = events.NewHub()
+
+// GetRouter builds the main router with the tictactoe subrouter, serving games out of db
+func GetRouter(db database.DB) *mux.Router {
 
 	mainRouter := mux.NewRouter().StrictSlash(true)
 
 	subRouter := mainRouter.PathPrefix("/tictactoe").Subrouter()
 	subRouter.HandleFunc("", RetrieveAllGames).Name("RetrieveAllGames").Methods("GET")
 	subRouter.HandleFunc("", CreateNewGame).Name("CreateNewGame").Methods("POST")
+	subRouter.HandleFunc("/lobby", RetrieveLobby).Name("RetrieveLobby").Methods("GET")
+	subRouter.HandleFunc("/lobby/queue", JoinMatchQueue).Name("JoinMatchQueue").Methods("POST")
+	subRouter.HandleFunc("/lobby/queue/{ticket_id}", RetrieveMatchTicket).Name("RetrieveMatchTicket").Methods("GET")
+	subRouter.HandleFunc("/lobby/queue/{ticket_id}", LeaveMatchQueue).Name("LeaveMatchQueue").Methods("DELETE")
 	subRouter.HandleFunc("/{game_id}", RetrieveGameState).Name("RetrieveGameState").Methods("GET")
 	subRouter.HandleFunc("/{game_id}/moves", RetrieveListOfMoves).Name("RetrieveListOfMoves").Methods("GET")
-	subRouter.HandleFunc("/{game_id}/{player_id}", PostAMove).Name("PostAMove").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/analysis", RetrieveAnalysis).Name("RetrieveAnalysis").Methods("GET")
+	subRouter.HandleFunc("/{game_id}/ws", WatchGameWebSocket).Name("WatchGameWebSocket").Methods("GET")
+	subRouter.HandleFunc("/{game_id}/events", StreamGameEvents).Name("StreamGameEvents").Methods("GET")
+	subRouter.HandleFunc("/{game_id}/join", JoinGame).Name("JoinGame").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}", PostAMove).Name("PostAMove").Methods("POST")
 	subRouter.HandleFunc("/{game_id}/moves/{move_number}", RetrieveAMove).Name("RetrieveAMove").Methods("GET")
-	subRouter.HandleFunc("/{game_id}/quit", QuitGame).Name("QuitGame").Methods("PUT")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/quit", QuitGame).Name("QuitGame").Methods("PUT")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/takeback", RequestTakeback).Name("RequestTakeback").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/takeback", AnswerTakeback).Name("AnswerTakeback").Methods("PUT")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/draw", OfferDraw).Name("OfferDraw").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/draw", AnswerDraw).Name("AnswerDraw").Methods("PUT")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/collapse", CollapseMark).Name("CollapseMark").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/opening", PlaceOpeningStones).Name("PlaceOpeningStones").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/color", ChooseColor).Name("ChooseColor").Methods("POST")
+	subRouter.HandleFunc("/{game_id}/{player_id:[0-9]+}/swap", SwapSides).Name("SwapSides").Methods("POST")
 
 	// assign the package DB client
-	GetNewDBClient()
+	dbClient = db
 
 	return mainRouter
 }
@@ -40,7 +61,11 @@ func CaselessMatcher(next http.Handler) http.Handler {
 	})
 }
 
-// GetNewDBClient initializes the DB client at the top of this file which can be used by the entire apiresources package
-func GetNewDBClient() {
-	dbClient = database.New()
+// CORS lets browsers on other origins call the API. Next to the simple requests it allows the PUT and DELETE routes,
+// the bearer token of a seat and the Last-Event-ID an event stream resumes from, which all need a preflight
+func CORS(next http.Handler) http.Handler {
+	return cors.New(cors.Options{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "Last-Event-ID"},
+	}).Handler(next)
 }
@@ -0,0 +1,44 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only router.go
+
+func TestCORSPreflightAllowsSeatToken(t *testing.T) {
+
+	handler := CORS(CaselessMatcher(GetRouter(&mocks.DB{})))
+
+	// a browser answering a takeback asks first whether it may send a PUT with the seat's token
+	r := httptest.NewRequest(http.MethodOptions, "/tictactoe/gameID1/1/takeback", nil)
+	r.Header.Set("Origin", "http://example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	r.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.MethodPut, w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+
+	// leaving the match queue is a DELETE, resuming an event stream sends the last event id
+	r = httptest.NewRequest(http.MethodOptions, "/tictactoe/lobby/queue/ticket1", nil)
+	r.Header.Set("Origin", "http://example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	r.Header.Set("Access-Control-Request-Headers", "Last-Event-ID")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.MethodDelete, w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Last-Event-Id", w.Header().Get("Access-Control-Allow-Headers"))
+}
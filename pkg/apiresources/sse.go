@@ -0,0 +1,207 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/events"
+)
+
+// ssePingPeriod is how often an idle stream gets a comment line, so proxies do not close it
+const ssePingPeriod = 30 * time.Second
+
+/*
+	StreamGameEvents streams the moves of a game as Server-Sent Events, for clients that cannot use the WebSocket
+	Every event carries the version of the game it brought about as its id, which only ever goes up.
+	A new client first receives every move already played. A reconnecting client sends the Last-Event-ID header
+	(browsers do this on their own). When the game changed since, it receives a takeback of every move followed by
+	the moves of the game as it is now, since moves taken back in between may have been replaced
+	The stream ends after the game is over. Reconnecting to a finished game that was already fully received returns 204
+
+	GET /tictactoe/{game_id}/events
+
+	Example Stream
+		event: move
+		id: 5
+		data: {"type":"MOVE","gameId":"gameUUID","moveNumber":4,"move":{"type":"MOVE","player":"player1","row":0,"col":2},"version":5}
+
+		event: complete
+		id: 5
+		data: {"type":"STATE","gameId":"gameUUID","state":"COMPLETE","winner":"player1","version":5}
+
+	event is one of move, collapse, color, swap, quit, draw, takeback, complete or state. The client drops every move
+	from the moveNumber of a takeback event on
+
+	StatusCodes
+	  200 Ok
+	  204 NoContent
+	  400 BadRequest
+	  404 NotFound
+	  500 InternalServerError
+*/
+func StreamGameEvents(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+
+	vars := mux.Vars(r)
+	gameID, ok := vars["game_id"]
+	if !ok {
+		http.Error(w, "game_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "game_id not provided"
+		json.NewEncoder(w).Encode(&response)
+		return
+	}
+
+	// the version of the game the client already has, -1 when it has nothing yet
+	lastEventID := -1
+	if lastEventIDStr := r.Header.Get("Last-Event-ID"); len(lastEventIDStr) > 0 {
+		var err error
+		lastEventID, err = strconv.Atoi(lastEventIDStr)
+		if err != nil {
+			http.Error(w, "Last-Event-ID must be an integer", http.StatusBadRequest)
+			*response.ErrorMessage = "Last-Event-ID must be an integer"
+			json.NewEncoder(w).Encode(&response)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		*response.ErrorMessage = "Streaming is not supported"
+		json.NewEncoder(w).Encode(&response)
+		return
+	}
+
+	// subscribe before reading the game, so nothing that happens in between is missed
+	sub := gameEvents.Subscribe(gameID)
+	defer sub.Close()
+
+	game, err := dbClient.GetGameWithID(gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		*response.ErrorMessage = err.Error()
+		json.NewEncoder(w).Encode(&response)
+		return
+	}
+
+	gameOver := isGameOver(game.State)
+	caughtUp := lastEventID == game.Version
+	if gameOver && caughtUp {
+		// the client has seen everything there will ever be, 204 tells it to stop reconnecting
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if !caughtUp {
+		// a client that is behind can not tell which of its moves were taken back since, it starts over
+		if lastEventID != -1 {
+			firstMove := 0
+			writeServerSentEvent(w, events.Event{Type: events.EventTypeTakeback, GameID: gameID, MoveNumber: &firstMove, Version: game.Version})
+		}
+
+		for i := range game.Moves {
+			moveNumber := i
+			writeServerSentEvent(w, events.Event{
+				Type:       events.EventTypeMove,
+				GameID:     gameID,
+				MoveNumber: &moveNumber,
+				Move:       &game.Moves[i],
+				Version:    game.Version,
+			})
+		}
+	}
+
+	if gameOver {
+		writeServerSentEvent(w, events.Event{Type: events.EventTypeState, GameID: gameID, State: game.State, Winner: game.Winner, Version: game.Version})
+		flusher.Flush()
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(ssePingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// we fell too far behind, the client reconnects with its Last-Event-ID and catches up
+				return
+			}
+
+			if event.Version <= game.Version {
+				// the game we caught the client up with already holds it
+				continue
+			}
+
+			writeServerSentEvent(w, event)
+			flusher.Flush()
+
+			if event.Type == events.EventTypeState && isGameOver(event.State) {
+				return
+			}
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes a single event in the text/event-stream format, with the version of the game as its id
+func writeServerSentEvent(w http.ResponseWriter, event events.Event) {
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Failed to encode event for game %s. Err: %s\n", event.GameID, err.Error())
+		return
+	}
+
+	fmt.Fprintf(w, "event: %s\n", serverSentEventName(event))
+	fmt.Fprintf(w, "id: %d\n", event.Version)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// serverSentEventName picks the event name clients can listen for
+func serverSentEventName(event events.Event) string {
+
+	switch {
+	case event.Type == events.EventTypeMove && event.Move.Type == database.MoveTypeQuit:
+		return "quit"
+	case event.Type == events.EventTypeMove && event.Move.Type == database.MoveTypeDraw:
+		return "draw"
+	case event.Type == events.EventTypeMove && event.Move.Type == database.MoveTypeCollapse:
+		return "collapse"
+	case event.Type == events.EventTypeMove && event.Move.Type == database.MoveTypeColor:
+		return "color"
+	case event.Type == events.EventTypeMove && event.Move.Type == database.MoveTypeSwap:
+		return "swap"
+	case event.Type == events.EventTypeMove:
+		return "move"
+	case event.Type == events.EventTypeTakeback:
+		return "takeback"
+	case event.State == database.StateQuit:
+		return "quit"
+	case event.State == database.StateComplete:
+		return "complete"
+	default:
+		return "state"
+	}
+}
+
+// isGameOver returns true for the states a game never leaves
+func isGameOver(state database.State) bool {
+	return state == database.StateComplete || state == database.StateQuit
+}
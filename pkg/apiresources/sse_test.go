@@ -0,0 +1,115 @@
+package apiresources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only sse.go
+
+func TestStreamGameEventsNewClientReceivesWholeGame(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	winner := "player1"
+	game := generateGameWithBoard(3, 3, 3)
+	game.State = database.StateComplete
+	game.Winner = &winner
+	game.Version = 3
+	game.Moves = []database.Move{
+		{Type: database.MoveTypeMove, Player: "player1", Row: 0, Col: 0},
+		{Type: database.MoveTypeMove, Player: "player2", Row: 1, Col: 1},
+		{Type: database.MoveTypeMove, Player: "player1", Row: 2, Col: 2},
+	}
+	dbMock.On("GetGameWithID", "gameID1").Return(game, nil)
+
+	w := httptest.NewRecorder()
+	StreamGameEvents(w, newEventsRequest("gameID1", ""))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.NotContains(t, body, "event: takeback")
+	assert.Equal(t, 3, strings.Count(body, "event: move\nid: 3\n"))
+	assert.Contains(t, body, "event: complete\nid: 3\n")
+	assert.True(t, strings.Index(body, `"moveNumber":1`) < strings.Index(body, `"moveNumber":2`))
+}
+
+func TestStreamGameEventsResumesAcrossTakeback(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	// the client left at version 2 with two moves, then the second was taken back and played elsewhere.
+	// Move 1 is a different move now, under the same move number
+	game := generateGameWithBoard(3, 3, 3)
+	game.Version = 4
+	game.Moves = []database.Move{
+		{Type: database.MoveTypeMove, Player: "player1", Row: 0, Col: 0},
+		{Type: database.MoveTypeMove, Player: "player2", Row: 2, Col: 2},
+	}
+	dbMock.On("GetGameWithID", "gameID1").Return(game, nil)
+
+	// the game is not over, the client going away ends the stream once it caught up
+	r := newEventsRequest("gameID1", "2")
+	ctx, cancel := context.WithCancel(r.Context())
+	cancel()
+
+	w := httptest.NewRecorder()
+	StreamGameEvents(w, r.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	takeback := strings.Index(body, "event: takeback\nid: 4\n")
+	replacement := strings.Index(body, `"moveNumber":1,"move":{"type":"MOVE","player":"player2","row":2,"col":2}`)
+	assert.NotEqual(t, -1, takeback)
+	assert.NotEqual(t, -1, replacement)
+	assert.True(t, takeback < replacement)
+	assert.Contains(t, body, `"type":"TAKEBACK","gameId":"gameID1","moveNumber":0`)
+}
+
+func TestStreamGameEventsFinishedGameAlreadyReceived(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.State = database.StateQuit
+	game.Version = 2
+	game.Moves = []database.Move{{Type: database.MoveTypeMove, Player: "player1", Row: 0, Col: 0}}
+	dbMock.On("GetGameWithID", "gameID1").Return(game, nil)
+
+	w := httptest.NewRecorder()
+	StreamGameEvents(w, newEventsRequest("gameID1", "2"))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func newEventsRequest(gameID, lastEventID string) *http.Request {
+	r := &http.Request{
+		Method: http.MethodGet,
+		URL: &url.URL{
+			Path: "/tictactoe/" + gameID + "/events",
+		},
+		Header: http.Header{"Last-Event-Id": []string{lastEventID}},
+	}
+
+	return mux.SetURLVars(r, map[string]string{
+		"game_id": gameID,
+	})
+}
@@ -0,0 +1,337 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/validator"
+)
+
+/*
+	RequestTakeback asks the opponent to undo the last moves of a casual game
+	Only one takeback can wait for an answer at a time, and the next move played answers it with a no.
+	The computer always agrees, so against it the moves are taken back straight away
+
+	POST /tictactoe/{game_id}/{player_id}/takeback
+
+	Example Request
+		{
+			"moves": 2
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {"takeback": {"playerId": 0, "moves": 2}, "accepted": false}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest, the game is rated or does not have that many moves after its opening
+	  401 Unauthorized
+	  403 Forbidden
+	  404 NotFound
+	  409 Conflict, the game is over, a takeback is already waiting or the game was updated by another request
+	  500 InternalServerError
+*/
+func RequestTakeback(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type TakebackRequest struct {
+		Moves *int `json:"moves" validate:"required,gte=1"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	takebackRequest := TakebackRequest{}
+	err = json.Unmarshal(requestBody, &takebackRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(takebackRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	takeback := database.Takeback{PlayerIdx: playerID, Moves: *takebackRequest.Moves}
+	accepted := false
+	_, e = updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkTakebackAllowed(game, playerID); e != nil {
+			return e
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		if game.PendingTakeback != nil {
+			return newStatusError(http.StatusConflict, "Player %d already asked for a takeback, it has to be answered first", game.PendingTakeback.PlayerIdx)
+		}
+
+		if takeback.Moves > len(game.Moves) {
+			return newStatusError(http.StatusBadRequest, "Can not take back %d moves, only %d were played", takeback.Moves, len(game.Moves))
+		}
+
+		if takeback.Moves > len(game.Moves)-openingLength(game) {
+			return newStatusError(http.StatusBadRequest, "Can not take back %d moves, the opening stays and only %d were played after it", takeback.Moves, len(game.Moves)-openingLength(game))
+		}
+
+		// the computer never says no
+		if _, ok := game.Bots[opponentOf(playerID)]; ok {
+			accepted = true
+			return acceptTakeback(game, takeback)
+		}
+
+		game.PendingTakeback = &takeback
+		return nil
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"takeback": takeback,
+		"accepted": accepted,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+	AnswerTakeback lets the opponent accept or decline the takeback waiting on a game
+	On acceptance the moves are removed from the game, their squares are cleared and the turn goes back to the player
+	who made the first of them
+
+	PUT /tictactoe/{game_id}/{player_id}/takeback
+
+	Example Request
+		{
+			"accept": true
+		}
+
+	Example Response
+		{
+			"error": null,
+			"data": {"takeback": {"playerId": 0, "moves": 2}, "accepted": true}
+		}
+
+	StatusCodes
+	  200 Ok
+	  400 BadRequest
+	  401 Unauthorized
+	  403 Forbidden, only the opponent of the player asking can answer
+	  404 NotFound, also when no takeback is waiting
+	  409 Conflict, the game is over or was updated by another request
+	  500 InternalServerError
+*/
+func AnswerTakeback(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+	defer json.NewEncoder(w).Encode(&response)
+
+	type AnswerRequest struct {
+		Accept *bool `json:"accept" validate:"required"`
+	}
+
+	v := validator.New()
+
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	answerRequest := AnswerRequest{}
+	err = json.Unmarshal(requestBody, &answerRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		*response.ErrorMessage = err.Error()
+		return
+	}
+
+	errStr := v.ValidateStruct(answerRequest)
+	if errStr != nil {
+		http.Error(w, *errStr, http.StatusBadRequest)
+		response.ErrorMessage = errStr
+		return
+	}
+
+	gameID, playerID, e := gameAndPlayerFromPath(r)
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	var takeback database.Takeback
+	_, e = updateGame(gameID, func(game *database.Game) *statusError {
+
+		if e := checkTakebackAllowed(game, playerID); e != nil {
+			return e
+		}
+
+		if e := authorizeSeat(r, game, playerID); e != nil {
+			return e
+		}
+
+		if game.PendingTakeback == nil {
+			return newStatusError(http.StatusNotFound, "No takeback is waiting for an answer in game %s", gameID)
+		}
+
+		if game.PendingTakeback.PlayerIdx == playerID {
+			return newStatusError(http.StatusForbidden, "Player %d can not answer their own takeback", playerID)
+		}
+
+		takeback = *game.PendingTakeback
+		game.PendingTakeback = nil
+		if !*answerRequest.Accept {
+			return nil
+		}
+
+		return acceptTakeback(game, takeback)
+	})
+	if e != nil {
+		http.Error(w, e.Error(), e.status)
+		*response.ErrorMessage = e.Error()
+		return
+	}
+
+	response.Data = map[string]interface{}{
+		"takeback": takeback,
+		"accepted": *answerRequest.Accept,
+	}
+	response.ErrorMessage = nil
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// gameAndPlayerFromPath reads the game_id and player_id of a request acting for a seat
+func gameAndPlayerFromPath(r *http.Request) (string, int, *statusError) {
+
+	vars := mux.Vars(r)
+	gameID, ok := vars["game_id"]
+	if !ok {
+		return "", -1, newStatusError(http.StatusBadRequest, "game_id not provided")
+	}
+
+	playerIDStr, ok := vars["player_id"]
+	if !ok {
+		return "", -1, newStatusError(http.StatusBadRequest, "player_id not provided")
+	}
+	playerID, err := strconv.Atoi(playerIDStr)
+	if err != nil {
+		return "", -1, newStatusError(http.StatusBadRequest, "player_id must be an integer")
+	}
+
+	return gameID, playerID, nil
+}
+
+// checkTakebackAllowed rejects takebacks in games that are over or rated, and for players not in the game
+func checkTakebackAllowed(game *database.Game, playerID int) *statusError {
+
+	if game.State != database.StateInProgress {
+		return newStatusError(http.StatusConflict, "Moves can not be taken back, game %s is %s", game.ID, game.State)
+	}
+
+	if game.Rated {
+		return newStatusError(http.StatusBadRequest, "Moves can not be taken back in a rated game")
+	}
+
+	if len(game.Players) > playersPerGame {
+		return newStatusError(http.StatusBadRequest, "Moves can only be taken back in a game of two players")
+	}
+
+	if game.Variant == database.VariantQuantum {
+		return newStatusError(http.StatusBadRequest, "Moves can not be taken back in a quantum game")
+	}
+
+	if _, ok := game.Players[playerID]; !ok {
+		return newStatusError(http.StatusNotFound, "Player with playerID %d is not found", playerID)
+	}
+
+	return nil
+}
+
+// acceptTakeback undoes the moves of the takeback, then lets the computer move again if the turn went back to it
+func acceptTakeback(game *database.Game, takeback database.Takeback) *statusError {
+
+	// a player who ran out of time while the takeback waited has lost, the clock scheduler completes the game
+	at := now()
+	if flagFell(game, at) {
+		return newStatusError(http.StatusConflict, "Player %d ran out of time", game.NextPlayerIdx)
+	}
+
+	stopClock(game, at)
+	undoMoves(game, takeback.Moves)
+	resumeClock(game, at)
+
+	if _, err := playBotMove(game); err != nil {
+		return newStatusError(http.StatusInternalServerError, "Failed to play the computer's move. %s", err.Error())
+	}
+
+	return nil
+}
+
+// undoMoves removes the last n moves, clears their squares and hands the turn to the player who made the earliest of them.
+// Once every move is gone the seat that opened the game moves again, as when it started
+func undoMoves(game *database.Game, n int) {
+
+	for i := 0; i < n; i++ {
+		last := game.Moves[len(game.Moves)-1]
+		if game.Variant == database.VariantUltimate {
+			undoUltimateMove(game, last)
+			game.Moves = game.Moves[:len(game.Moves)-1]
+			continue
+		}
+
+		row := boardRow(game, last)
+		if placesSymbols(game) {
+			// the board holds symbols rather than players, but turns always alternate
+			game.NextPlayerIdx = opponentOf(game.NextPlayerIdx)
+		} else if game.Variant == database.VariantNumerical {
+			// the number goes back to the player who placed it
+			game.NextPlayerIdx = engine.NumericalOwner(last.Value)
+			returnNumber(game, game.NextPlayerIdx, last.Value)
+		} else {
+			game.NextPlayerIdx = markSeat(game, game.GameBoard[row][last.Col])
+		}
+		game.GameBoard[row][last.Col] = -1
+		game.Moves = game.Moves[:len(game.Moves)-1]
+	}
+
+	// the first move may have been made by either player, the turn goes back to the seat startGame picked
+	if len(game.Moves) == 0 {
+		game.NextPlayerIdx = openingSeat(game)
+	}
+}
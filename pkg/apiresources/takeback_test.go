@@ -0,0 +1,104 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/mocks"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// A test file for only takeback.go
+
+func TestTakebackAccepted(t *testing.T) {
+
+	// three moves in, player 1 is to move
+	stored := generateGameWithBoard(3, 3, 3)
+	for i, square := range [][2]int{{1, 1}, {0, 0}, {2, 2}} {
+		_, err := applyMove(square[0], square[1], i%2, &stored)
+		assert.NoError(t, err)
+	}
+
+	storeGame(&stored)
+
+	sub := gameEvents.Subscribe("gameID1")
+	defer sub.Close()
+
+	// player 1 wants their move and player 0's reply back
+	w := httptest.NewRecorder()
+	RequestTakeback(w, newActionRequest(http.MethodPost, "1", "takeback", `{"moves": 2}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &database.Takeback{PlayerIdx: 1, Moves: 2}, stored.PendingTakeback)
+
+	// asking again before an answer is rejected, and so is answering your own takeback
+	w = httptest.NewRecorder()
+	RequestTakeback(w, newActionRequest(http.MethodPost, "1", "takeback", `{"moves": 1}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	AnswerTakeback(w, newActionRequest(http.MethodPut, "1", "takeback", `{"accept": true}`))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	AnswerTakeback(w, newActionRequest(http.MethodPut, "0", "takeback", `{"accept": true}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, stored.PendingTakeback)
+	assert.Len(t, stored.Moves, 1)
+	assert.Equal(t, -1, stored.GameBoard[0][0])
+	assert.Equal(t, -1, stored.GameBoard[2][2])
+	assert.Equal(t, 0, stored.GameBoard[1][1])
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+
+	// watchers are told which moves are gone
+	event := <-sub.Events
+	assert.Equal(t, events.EventTypeTakeback, event.Type)
+	assert.Equal(t, 1, *event.MoveNumber)
+}
+
+func TestUndoEveryMoveHandsTheTurnToTheOpeningSeat(t *testing.T) {
+
+	// player 1 opened a classic game, once both moves are gone either player may open again
+	game := generateGameWithBoard(3, 3, 3)
+	for i, square := range [][2]int{{1, 1}, {0, 0}} {
+		_, err := applyMove(square[0], square[1], 1-i, &game)
+		assert.NoError(t, err)
+	}
+
+	undoMoves(&game, 2)
+	assert.Empty(t, game.Moves)
+	assert.Equal(t, -1, game.NextPlayerIdx)
+
+	// Order always opens an order-chaos game
+	game = generateOrderAndChaosGame(1)
+	game.NextPlayerIdx = 1
+	_, err := applyMoveWithMark(0, 0, 1, 0, &game)
+	assert.NoError(t, err)
+
+	undoMoves(&game, 1)
+	assert.Equal(t, 1, game.NextPlayerIdx)
+}
+
+func TestTakebackRejectedInRatedGame(t *testing.T) {
+
+	// Setup the DB mock and assign it to the package dBClient interface
+	dbMock := mocks.DB{}
+	dbClient = &dbMock
+
+	dbMock.On("GetGameWithID", "gameID1").Return(func(string) database.Game {
+		game := generateGameWithBoard(3, 3, 3)
+		game.Rated = true
+		_, _ = applyMove(1, 1, 0, &game)
+		return game
+	}, nil)
+
+	w := httptest.NewRecorder()
+	RequestTakeback(w, newActionRequest(http.MethodPost, "0", "takeback", `{"moves": 1}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	dbMock.AssertNotCalled(t, "CompareAndSwapGame", mock.Anything)
+}
@@ -0,0 +1,70 @@
+package apiresources
+
+import (
+	"sort"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+)
+
+// activeSeats returns the seats of the game in order, leaving out every player who quit
+func activeSeats(game *database.Game) []int {
+
+	seats := []int{}
+	for seat := range game.Players {
+		if !hasQuit(game, seat) {
+			seats = append(seats, seat)
+		}
+	}
+	sort.Ints(seats)
+
+	return seats
+}
+
+// hasQuit returns true if the player at seat quit a party game that went on without them
+func hasQuit(game *database.Game, seat int) bool {
+
+	for _, quit := range game.QuitPlayers {
+		if quit == seat {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextSeat returns the first seat after playerID, in seat order, of a player still in the game
+// With two players it is simply the other one
+func nextSeat(game *database.Game, playerID int) int {
+
+	seats := activeSeats(game)
+	for _, seat := range seats {
+		if seat > playerID {
+			return seat
+		}
+	}
+
+	// wrap around to the lowest seat
+	return seats[0]
+}
+
+// opponentOf returns the seat playing against playerID
+func opponentOf(playerID int) int {
+	return (playerID + 1) % playersPerGame
+}
+
+// turnOrder returns the seats still playing in the order they move, starting from toMove
+func turnOrder(game *database.Game, toMove int) []int {
+
+	seats := activeSeats(game)
+
+	start := 0
+	for i, seat := range seats {
+		if seat >= toMove {
+			start = i
+			break
+		}
+	}
+
+	order := append([]int{}, seats[start:]...)
+	return append(order, seats[:start]...)
+}
@@ -0,0 +1,86 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only turns.go
+
+func TestPartyGameTurns(t *testing.T) {
+
+	stored := generatePartyGame()
+
+	storeGame(&stored)
+
+	post := func(playerID, body string) int {
+		w := httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", playerID, body))
+		return w.Code
+	}
+
+	// turns rotate in seat order
+	assert.Equal(t, http.StatusOK, post("0", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusOK, post("1", `{"row": 1, "column": 0}`))
+	assert.Equal(t, http.StatusOK, post("2", `{"row": 2, "column": 0}`))
+	assert.Equal(t, 2, stored.GameBoard[2][0])
+	assert.Equal(t, http.StatusConflict, post("1", `{"row": 1, "column": 1}`))
+	assert.Equal(t, http.StatusOK, post("0", `{"row": 0, "column": 1}`))
+
+	// player 1 quits on their turn, the game goes on and their turns are skipped
+	w := httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateInProgress, stored.State)
+	assert.Equal(t, []int{1}, stored.QuitPlayers)
+	assert.Equal(t, 2, stored.NextPlayerIdx)
+	assert.Equal(t, http.StatusConflict, post("1", `{"row": 1, "column": 1}`))
+
+	assert.Equal(t, http.StatusOK, post("2", `{"row": 2, "column": 1}`))
+	assert.Equal(t, 0, stored.NextPlayerIdx)
+	assert.Equal(t, http.StatusOK, post("0", `{"row": 0, "column": 2}`))
+	assert.Equal(t, http.StatusOK, post("2", `{"row": 3, "column": 3}`))
+
+	// the first player to complete a line wins
+	assert.Equal(t, http.StatusOK, post("0", `{"row": 0, "column": 3}`))
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player1", *stored.Winner)
+}
+
+func TestPartyGameLastPlayerLeftWins(t *testing.T) {
+
+	stored := generatePartyGame()
+	_, err := applyMove(0, 0, 0, &stored)
+	assert.NoError(t, err)
+
+	storeGame(&stored)
+
+	w := httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "2"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateInProgress, stored.State)
+
+	w = httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "2"))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	QuitGame(w, newQuitRequest("gameID1", "0"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.StateQuit, stored.State)
+	assert.Equal(t, "player2", *stored.Winner)
+}
+
+// generatePartyGame returns a game of three players on a 4x4 board, won with 4 in a row
+func generatePartyGame() database.Game {
+
+	game := generateGameWithBoard(4, 4, 4)
+	game.Players[2] = "player3"
+	game.TokenHashes[2] = hashSeatToken("token2")
+
+	return game
+}
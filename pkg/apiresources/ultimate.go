@@ -0,0 +1,133 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+// newUltimate returns the empty boards of a new ultimate game, the first move may be played on any of them
+func newUltimate() *database.Ultimate {
+	return &database.Ultimate{
+		Boards:      engine.NewUltimateBoards(),
+		MetaBoard:   engine.NewUltimateMetaBoard(),
+		ActiveBoard: engine.UltimateAnyBoard,
+	}
+}
+
+// postUltimateMove plays the board and the cell of the request body for playerID
+// Ultimate boards are kept apart from the GameBoard, both are numbered 0 to 8 in row major order
+func postUltimateMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type UltimateMoveRequest struct {
+		Board *int `json:"board" validate:"required,gte=0,lte=8"`
+		Cell  *int `json:"cell" validate:"required,gte=0,lte=8"`
+	}
+
+	moveRequest := UltimateMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "a board and a cell"); e != nil {
+		return -1, e
+	}
+
+	return playUltimateMove(game, playerID, *moveRequest.Board, *moveRequest.Cell)
+}
+
+// playUltimateMove places the mark of playerID in cell of board, claims the board when that decided it,
+// and sends the opponent to the board numbered like cell. Returns the moveNumber
+func playUltimateMove(game *database.Game, playerID, board, cell int) (int, *statusError) {
+
+	if err := checkUltimateMove(game, board, cell); err != nil {
+		return -1, newStatusError(http.StatusBadRequest, "Failed to play the move, it is illegal. %s", err.Error())
+	}
+
+	ultimate := game.Ultimate
+	ultimate.Boards[board][cell/engine.UltimateSide][cell%engine.UltimateSide] = playerID
+	ultimate.MetaBoard[board/engine.UltimateSide][board%engine.UltimateSide] = engine.UltimateBoardResult(ultimate.Boards[board])
+	ultimate.ActiveBoard = engine.UltimateNextBoard(ultimate.MetaBoard, cell)
+
+	game.Moves = append(game.Moves, database.Move{
+		Type:   database.MoveTypeMove,
+		Player: game.Players[playerID],
+		Board:  &board,
+		Cell:   &cell,
+	})
+
+	// moving on answers a takeback with a no, and declines the opponent's draw offer
+	game.PendingTakeback = nil
+	if game.DrawOfferedBy != nil && *game.DrawOfferedBy != playerID {
+		game.DrawOfferedBy = nil
+	}
+
+	game.NextPlayerIdx = nextSeat(game, playerID)
+	settleUltimate(game)
+	pressClock(game, playerID)
+
+	return len(game.Moves) - 1, nil
+}
+
+// checkUltimateMove makes sure cell of board is empty and lies on a board the player to move may play on
+func checkUltimateMove(game *database.Game, board, cell int) error {
+
+	ultimate := game.Ultimate
+	if ultimate.ActiveBoard != engine.UltimateAnyBoard && board != ultimate.ActiveBoard {
+		return fmt.Errorf("the move has to be played on board %d", ultimate.ActiveBoard)
+	}
+
+	if engine.UltimateCell(ultimate.MetaBoard, board) != engine.Empty {
+		return fmt.Errorf("board %d is already decided", board)
+	}
+
+	if engine.UltimateCell(ultimate.Boards[board], cell) != engine.Empty {
+		return fmt.Errorf("cell %d of board %d is already taken", cell, board)
+	}
+
+	return nil
+}
+
+// undoUltimateMove takes back move, the last move of an ultimate game, reopening its board when it decided it
+// The player who made it is to move again, on the board the move before sent them to
+func undoUltimateMove(game *database.Game, move database.Move) {
+
+	ultimate := game.Ultimate
+	board, cell := *move.Board, *move.Cell
+
+	game.NextPlayerIdx = engine.UltimateCell(ultimate.Boards[board], cell)
+	ultimate.Boards[board][cell/engine.UltimateSide][cell%engine.UltimateSide] = engine.Empty
+	ultimate.MetaBoard[board/engine.UltimateSide][board%engine.UltimateSide] = engine.UltimateBoardResult(ultimate.Boards[board])
+
+	ultimate.ActiveBoard = engine.UltimateAnyBoard
+	for i := len(game.Moves) - 2; i >= 0; i-- {
+		if game.Moves[i].Type == database.MoveTypeMove {
+			ultimate.ActiveBoard = engine.UltimateNextBoard(ultimate.MetaBoard, *game.Moves[i].Cell)
+			break
+		}
+	}
+}
+
+// settleUltimate completes the game once the meta board is won, or can no longer be won by anybody
+func settleUltimate(game *database.Game) {
+
+	mark, over := engine.UltimateResult(game.Ultimate.MetaBoard)
+	if !over {
+		return
+	}
+
+	game.State = database.StateComplete
+	if mark != engine.Empty {
+		winner := game.Players[mark]
+		game.Winner = &winner
+		return
+	}
+
+	game.DrawReason = database.DrawReasonNoWinnableLine
+	for _, row := range game.Ultimate.MetaBoard {
+		for _, cell := range row {
+			if cell == engine.Empty {
+				return
+			}
+		}
+	}
+	game.DrawReason = database.DrawReasonBoardFull
+}
@@ -0,0 +1,97 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only ultimate.go
+
+func TestPostAMoveUltimate(t *testing.T) {
+
+	stored := generateUltimateGame()
+
+	storeGame(&stored)
+
+	// cell 2 of the center board
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"board": 4, "cell": 2}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, stored.Ultimate.Boards[4][0][2])
+	assert.Equal(t, 2, stored.Ultimate.ActiveBoard)
+
+	board, cell := 4, 2
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player1", Board: &board, Cell: &cell}, stored.Moves[0])
+
+	// player 1 is sent to board 2
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"board": 4, "cell": 0}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"row": 0, "column": 6}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"board": 2, "cell": 4}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, stored.Ultimate.Boards[2][1][1])
+	assert.Equal(t, 4, stored.Ultimate.ActiveBoard)
+}
+
+func TestUltimateClaimsBoards(t *testing.T) {
+
+	game := generateUltimateGame()
+
+	// player 0 completes the top row of board 0
+	game.Ultimate.Boards[0][0] = []int{0, 0, engine.Empty}
+	_, err := playUltimateMove(&game, 0, 0, 2)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 0, game.Ultimate.MetaBoard[0][0])
+	assert.Equal(t, 2, game.Ultimate.ActiveBoard)
+
+	// a decided board takes no more moves, and sending a player to it lets them pick any open board
+	game.Ultimate.ActiveBoard = engine.UltimateAnyBoard
+	_, err = playUltimateMove(&game, 1, 0, 4)
+	assert.Equal(t, http.StatusBadRequest, err.status)
+
+	_, err = playUltimateMove(&game, 1, 3, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, engine.UltimateAnyBoard, game.Ultimate.ActiveBoard)
+}
+
+func TestUndoUltimateMove(t *testing.T) {
+
+	game := generateUltimateGame()
+	for _, move := range [][3]int{{0, 4, 2}, {1, 2, 4}, {0, 4, 0}} {
+		_, err := playUltimateMove(&game, move[0], move[1], move[2])
+		assert.Nil(t, err)
+	}
+
+	// player 0 is to move again on board 4, where player 1 sent them
+	undoMoves(&game, 1)
+	assert.Len(t, game.Moves, 2)
+	assert.Equal(t, engine.Empty, game.Ultimate.Boards[4][0][0])
+	assert.Equal(t, 0, game.NextPlayerIdx)
+	assert.Equal(t, 4, game.Ultimate.ActiveBoard)
+
+	undoMoves(&game, 2)
+	assert.Empty(t, game.Moves)
+	assert.Equal(t, engine.UltimateAnyBoard, game.Ultimate.ActiveBoard)
+	assert.Equal(t, newUltimate().Boards, game.Ultimate.Boards)
+}
+
+func generateUltimateGame() database.Game {
+	game := generateGameWithBoard(9, 9, 3)
+	game.Variant = database.VariantUltimate
+	game.GameBoard = [][]int{}
+	game.Ultimate = newUltimate()
+
+	return game
+}
@@ -0,0 +1,113 @@
+package apiresources
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/events"
+)
+
+// maxUpdateAttempts is how many times a read-modify-write is tried before giving up on a busy game
+const maxUpdateAttempts = 3
+
+// statusError is an error along with the HTTP status code it should be reported with
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// newStatusError builds a statusError from a format string, like fmt.Errorf
+func newStatusError(status int, format string, a ...interface{}) *statusError {
+	return &statusError{status: status, message: fmt.Sprintf(format, a...)}
+}
+
+/*
+	updateGame reads a game, applies change to it and writes it back only if no other request updated the game in between
+	When another request wins the race the game is read again and change is applied to the fresh copy, so change must
+	only depend on the game it is handed. A change that returns an error aborts the update and nothing is written
+	Returns the game as it was stored, or a statusError ready to be reported to the client:
+	  404 NotFound when the game does not exist
+	  409 Conflict when the game kept changing underneath us
+	  500 InternalServerError when the DB failed
+*/
+func updateGame(gameID string, change func(game *database.Game) *statusError) (database.Game, *statusError) {
+
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+
+		game, err := dbClient.GetGameWithID(gameID)
+		if err != nil {
+			fmt.Printf("Failed to find game with gameID %s. Err: %s\n", gameID, err.Error())
+			return database.Game{}, newStatusError(http.StatusNotFound, "%s", err.Error())
+		}
+
+		stateBefore := game.State
+		movesBefore := append([]database.Move{}, game.Moves...)
+
+		if e := change(&game); e != nil {
+			return game, e
+		}
+
+		err = dbClient.CompareAndSwapGame(game)
+		if err == nil {
+			game.Version++
+			publishGameEvents(game, stateBefore, movesBefore)
+			return game, nil
+		}
+
+		if err != database.ErrVersionConflict {
+			return game, newStatusError(http.StatusInternalServerError, "Failed to update the game in the DB. %s", err.Error())
+		}
+
+		fmt.Printf("Game %s was updated by another request, attempt %d of %d\n", gameID, attempt, maxUpdateAttempts)
+	}
+
+	return database.Game{}, newStatusError(http.StatusConflict, "Game %s is being updated by another request, please try again", gameID)
+}
+
+// publishGameEvents tells everyone watching the game about the moves taken back, the moves appended and its new state
+func publishGameEvents(game database.Game, stateBefore database.State, movesBefore []database.Move) {
+
+	// the moves both versions of the game agree on were left alone
+	kept := 0
+	for kept < len(movesBefore) && kept < len(game.Moves) && reflect.DeepEqual(movesBefore[kept], game.Moves[kept]) {
+		kept++
+	}
+
+	if kept < len(movesBefore) {
+		moveNumber := kept
+		gameEvents.Publish(events.Event{
+			Type:       events.EventTypeTakeback,
+			GameID:     game.ID,
+			MoveNumber: &moveNumber,
+			Version:    game.Version,
+		})
+	}
+
+	for i := kept; i < len(game.Moves); i++ {
+		moveNumber := i
+		move := game.Moves[i]
+		gameEvents.Publish(events.Event{
+			Type:       events.EventTypeMove,
+			GameID:     game.ID,
+			MoveNumber: &moveNumber,
+			Move:       &move,
+			Version:    game.Version,
+		})
+	}
+
+	if game.State != stateBefore {
+		gameEvents.Publish(events.Event{
+			Type:   events.EventTypeState,
+			GameID: game.ID,
+			State:   game.State,
+			Winner:  game.Winner,
+			Version: game.Version,
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package apiresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/events"
+)
+
+const (
+	// websocketPingPeriod is how often an idle connection is pinged to notice clients that went away
+	websocketPingPeriod = 30 * time.Second
+
+	// websocketWriteWait is how long a single write to a client may take
+	websocketWriteWait = 10 * time.Second
+)
+
+// the API is already open to every origin through CORS, so the WebSocket is too
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+/*
+	WatchGameWebSocket upgrades the connection to a WebSocket that pushes every change to a game as it happens
+	Anyone can watch, players and spectators alike. The first message is the current state of the game,
+	then one message per new move and per state change. Messages sent by the client are ignored
+
+	GET /tictactoe/{game_id}/ws
+
+	Example Messages
+		{"type": "STATE", "gameId": "gameUUID", "state": "IN_PROGRESS"}
+		{"type": "MOVE", "gameId": "gameUUID", "moveNumber": 4, "move": {"type": "MOVE", "player": "player1", "row": 1, "col": 1}}
+		{"type": "STATE", "gameId": "gameUUID", "state": "COMPLETE", "winner": "player1"}
+
+	StatusCodes
+	  101 SwitchingProtocols
+	  400 BadRequest
+	  404 NotFound
+*/
+func WatchGameWebSocket(w http.ResponseWriter, r *http.Request) {
+
+	response := Response{ErrorMessage: new(string)}
+
+	vars := mux.Vars(r)
+	gameID, ok := vars["game_id"]
+	if !ok {
+		http.Error(w, "game_id not provided", http.StatusBadRequest)
+		*response.ErrorMessage = "game_id not provided"
+		json.NewEncoder(w).Encode(&response)
+		return
+	}
+
+	// subscribe before reading the game, so nothing that happens in between is missed
+	sub := gameEvents.Subscribe(gameID)
+	defer sub.Close()
+
+	game, err := dbClient.GetGameWithID(gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		*response.ErrorMessage = err.Error()
+		json.NewEncoder(w).Encode(&response)
+		return
+	}
+
+	// the upgrader answers the client itself when the handshake fails
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		fmt.Printf("Failed to upgrade to a WebSocket for game %s. Err: %s\n", gameID, err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// read until the client goes away, the only messages we care about are the control frames
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	snapshot := events.Event{Type: events.EventTypeState, GameID: gameID, State: game.State, Winner: game.Winner, Version: game.Version}
+	if err := writeWebSocketEvent(conn, snapshot); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(websocketPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// we fell too far behind, the client reconnects and starts from a fresh snapshot
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(websocketWriteWait))
+				return
+			}
+			if err := writeWebSocketEvent(conn, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// writeWebSocketEvent sends a single event as a JSON text message
+func writeWebSocketEvent(conn *websocket.Conn, event events.Event) error {
+
+	conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+	return conn.WriteJSON(event)
+}
@@ -0,0 +1,66 @@
+package apiresources
+
+import (
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/engine"
+)
+
+// wildSymbols are the symbols of wild and order-chaos games, indexed by the mark stored on the GameBoard
+var wildSymbols = []database.Symbol{database.SymbolX, database.SymbolO}
+
+// placesSymbols returns true for the variants where either player may place either symbol
+func placesSymbols(game *database.Game) bool {
+	return game.Variant == database.VariantWild || game.Variant == database.VariantOrderAndChaos
+}
+
+// postSymbolMove places the symbol of the request body, X or O, at its row and column for playerID
+// Either player may place either symbol in wild and order-chaos games. A wild game with gravity only takes the column
+func postSymbolMove(game *database.Game, playerID int, body []byte) (int, *statusError) {
+
+	type SymbolMoveRequest struct {
+		Row    *int   `json:"row" validate:"omitempty,gte=0"`
+		Column *int   `json:"column" validate:"required,gte=0"`
+		Symbol string `json:"symbol" validate:"required,oneof=X O"`
+	}
+
+	moveRequest := SymbolMoveRequest{}
+	if e := decodeMoveRequest(game, body, &moveRequest, "a row, a column and a symbol, X or O"); e != nil {
+		return -1, e
+	}
+
+	row, col, e := boardSquare(game, moveRequest.Row, *moveRequest.Column)
+	if e != nil {
+		return -1, e
+	}
+
+	return placeMark(game, playerID, row, col, symbolMark(database.Symbol(moveRequest.Symbol)))
+}
+
+// symbolMark returns the mark the GameBoard stores for symbol
+func symbolMark(symbol database.Symbol) int {
+
+	for mark, s := range wildSymbols {
+		if s == symbol {
+			return mark
+		}
+	}
+
+	return -1
+}
+
+// settleWild completes the game once the move at row, col completed a line of a single symbol, whichever it was,
+// the board is full, or no line can be completed any more
+func settleWild(game *database.Game, row, col, playerID int) {
+
+	if engine.IsWinningMove(game.GameBoard, row, col, game.WinLength) {
+		winner := game.Players[playerID]
+		game.State = database.StateComplete
+		game.Winner = &winner
+	} else if engine.IsFull(game.GameBoard) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonBoardFull
+	} else if !engine.WildCanStillBeWon(game.GameBoard, game.WinLength) {
+		game.State = database.StateComplete
+		game.DrawReason = database.DrawReasonNoWinnableLine
+	}
+}
@@ -0,0 +1,61 @@
+package apiresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only wild.go
+
+func TestPostAMoveWild(t *testing.T) {
+
+	stored := generateGameWithBoard(3, 3, 3)
+	stored.Variant = database.VariantWild
+
+	storeGame(&stored)
+
+	// a move needs a symbol
+	w := httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "0", `{"row": 0, "column": 0}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// both players place Os along the top row, and the player completing it wins
+	for i, body := range []string{`{"row": 0, "column": 0, "symbol": "O"}`, `{"row": 1, "column": 1, "symbol": "X"}`, `{"row": 0, "column": 1, "symbol": "O"}`} {
+		w = httptest.NewRecorder()
+		PostAMove(w, newMoveRequest("gameID1", []string{"0", "1"}[i%2], body))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	assert.Equal(t, 1, stored.GameBoard[0][0])
+	assert.Equal(t, 0, stored.GameBoard[1][1])
+	assert.Equal(t, database.Move{Type: database.MoveTypeMove, Player: "player2", Row: 1, Col: 1, Symbol: database.SymbolX}, stored.Moves[1])
+
+	w = httptest.NewRecorder()
+	PostAMove(w, newMoveRequest("gameID1", "1", `{"row": 0, "column": 2, "symbol": "O"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, database.StateComplete, stored.State)
+	assert.Equal(t, "player2", *stored.Winner)
+}
+
+func TestUndoWildMove(t *testing.T) {
+
+	game := generateGameWithBoard(3, 3, 3)
+	game.Variant = database.VariantWild
+
+	_, err := applyMoveWithMark(0, 0, 0, 1, &game)
+	assert.NoError(t, err)
+
+	// player 1 answers with an X, which is stored as mark 0
+	_, err = applyMoveWithMark(1, 1, 1, 0, &game)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, game.NextPlayerIdx)
+
+	undoMoves(&game, 1)
+	assert.Equal(t, -1, game.GameBoard[1][1])
+	assert.Equal(t, 1, game.NextPlayerIdx)
+}
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"time"
 )
 
 /*
@@ -15,6 +17,16 @@ import (
 // Custom typing for some known string values
 type MoveType string
 type State string
+type BotDifficulty string
+type ForfeitReason string
+type DrawReason string
+type Variant string
+type RuleSet string
+type Symbol string
+type Role string
+type OpeningRule string
+type OpeningStage string
+type Color string
 
 /*
 	ticTacToeDBTable is the structure that represents a database table
@@ -30,17 +42,120 @@ const (
 	MoveTypeMove MoveType = "MOVE"
 	MoveTypeQuit MoveType = "QUIT"
 
+	// MoveTypeDraw is recorded for the player accepting a draw offer, it ends the game without a winner
+	MoveTypeDraw MoveType = "DRAW"
+
+	// MoveTypeCollapse is recorded for the player deciding which square the spooky mark closing a cycle collapses into
+	MoveTypeCollapse MoveType = "COLLAPSE"
+
+	// MoveTypeSwap is recorded for the second player taking over the first move under the pie rule, and their seats trade places
+	MoveTypeSwap MoveType = "SWAP"
+
+	// MoveTypeColor is recorded for the player choosing the color they play after a swap or swap2 opening
+	MoveTypeColor MoveType = "COLOR"
+
 	StateComplete   State = "COMPLETE"
 	StateInProgress State = "IN_PROGRESS"
 	StateQuit       State = "QUIT"
+
+	// StateWaitingForPlayers is a game sitting in the lobby with an open seat
+	StateWaitingForPlayers State = "WAITING_FOR_PLAYERS"
+
+	BotDifficultyRandom  BotDifficulty = "random"
+	BotDifficultyEasy    BotDifficulty = "easy"
+	BotDifficultyPerfect BotDifficulty = "perfect"
+
+	// ForfeitReasonQuit is a player quitting a game that had already started
+	ForfeitReasonQuit ForfeitReason = "QUIT"
+
+	// ForfeitReasonTimeout is a player running out of time on their clock
+	ForfeitReasonTimeout ForfeitReason = "TIMEOUT"
+
+	// ForfeitReasonAbandoned is a player matched from the queue never picking up their seat, the game is called off
+	ForfeitReasonAbandoned ForfeitReason = "ABANDONED"
+
+	// DrawReasonBoardFull is a draw with no square left to play
+	DrawReasonBoardFull DrawReason = "BOARD_FULL"
+
+	// DrawReasonNoWinnableLine is a draw called early, once neither player can complete a line any more
+	DrawReasonNoWinnableLine DrawReason = "NO_WINNABLE_LINE"
+
+	// DrawReasonAgreed is a draw offered by one player and accepted by the other
+	DrawReasonAgreed DrawReason = "AGREED"
+
+	// VariantClassic is the default, lines of WinLength squares on a single board
+	VariantClassic Variant = "classic"
+
+	// VariantUltimate is a 3x3 grid of 3x3 boards, stored in Ultimate rather than the GameBoard. The cell played picks the opponent's board
+	VariantUltimate Variant = "ultimate"
+
+	// VariantQubic is a 4x4x4 cube whose layers are stacked into a 16x4 GameBoard, won by 4 in a row along any line
+	VariantQubic Variant = "qubic"
+
+	// VariantWild lets either player place X or O on their turn, the GameBoard then holds symbols rather than players
+	VariantWild Variant = "wild"
+
+	// VariantOrderAndChaos is played on a 6x6 board where both players place X or O. Order wins with five in a row
+	// of a single symbol, Chaos wins once the board fills up without one
+	VariantOrderAndChaos Variant = "order-chaos"
+
+	// VariantQuantum places spooky marks in two squares at once on a 3x3 board. Once they form a cycle they collapse
+	// into classical marks, the only ones the GameBoard holds
+	VariantQuantum Variant = "quantum"
+
+	// VariantNumerical has the first player place the odd numbers from 1 to 9 and the second the even ones, each once.
+	// A line adding up to 15 wins, and the GameBoard holds the numbers placed rather than players
+	VariantNumerical Variant = "numerical"
+
+	// SymbolX and SymbolO are the symbols of wild and order-chaos games, stored on their GameBoard as 0 and 1
+	SymbolX Symbol = "X"
+	SymbolO Symbol = "O"
+
+	// RoleOrder and RoleChaos are the two sides of an order-chaos game
+	RoleOrder Role = "ORDER"
+	RoleChaos Role = "CHAOS"
+
+	// ColorBlack and ColorWhite are the stones of games with an opening, stored on their GameBoard as 0 and 1. Black moves first
+	ColorBlack Color = "BLACK"
+	ColorWhite Color = "WHITE"
+
+	// OpeningPro has black open in the center of the board, and play its second stone outside the 5x5 square around it
+	OpeningPro OpeningRule = "pro"
+
+	// OpeningSwap has player 0 place the first three stones, black, white and black, and player 1 choose the color they play
+	OpeningSwap OpeningRule = "swap"
+
+	// OpeningSwap2 is OpeningSwap where player 1 may place a white and a black stone instead, and leave the choice to player 0
+	OpeningSwap2 OpeningRule = "swap2"
+
+	// OpeningStagePlace is a swap opening waiting for player 0 to place the first three stones
+	OpeningStagePlace OpeningStage = "PLACE"
+
+	// OpeningStageChoose is a swap opening waiting for the player to move to choose their color,
+	// or in swap2 for player 1 to place two more stones instead
+	OpeningStageChoose OpeningStage = "CHOOSE"
+
+	// RuleSetStandard is the default, completing a line wins
+	RuleSetStandard RuleSet = "standard"
+
+	// RuleSetMisere turns the game around, completing a line loses
+	RuleSetMisere RuleSet = "misere"
+
+	// RuleSetNotakto has both players place the same mark on one or more boards, stacked into the GameBoard.
+	// Completing a line kills its board, and whoever kills the last board loses
+	RuleSetNotakto RuleSet = "notakto"
 )
 
+// ErrVersionConflict is returned by CompareAndSwapGame when the game was updated since the caller read it
+var ErrVersionConflict = errors.New("The game was updated by another request")
+
 // DB is the interface that holds the methods for accessing the TicTacToe DB
 type DB interface {
 	GetGameWithID(id string) (Game, error)
 	GetAllGames() ([]Game, error)
 	CreateNewGame(game Game) (string, error)
 	UpdateGame(game Game) error
+	CompareAndSwapGame(game Game) error
 }
 
 // Client is the client the implements the DB interface. The holds access to the InMemory ticTacToeDBTable
@@ -59,19 +174,338 @@ type Game struct {
 	Players       map[int]string `json:"players"`
 	Columns       int            `json:"columns"`
 	Rows          int            `json:"rows"`
+	WinLength     int            `json:"winLength"` // The number of consecutive squares needed to win
 	State         State          `json:"state"`
 	Winner        *string        `json:"winner"`
 	Moves         []Move         `json:"moves"`
-	NextPlayerIdx int            `json:"nextPlayerIdx"` // The index into the Player array of the next move, either 0 or 1
+	NextPlayerIdx int            `json:"nextPlayerIdx"` // The index into the Player array of the next move
 	GameBoard     [][]int        `json:"gameBoard"`     // The game board
+	Version       int            `json:"version"`       // Incremented on every update, used to reject stale writes
+
+	// Bots maps the index into the Player array of each seat played by the computer to its difficulty
+	Bots map[int]BotDifficulty `json:"bots,omitempty"`
+
+	// TokenHashes maps the index into the Player array of each human seat to the hash of the secret token acting for it
+	TokenHashes map[int]string `json:"tokenHashes,omitempty"`
+
+	// Forfeit is set when a player gave the game up or ran out of time
+	// The opponent is the Winner of a timeout, and of a quit when at least one move had been played
+	Forfeit *Forfeit `json:"forfeit,omitempty"`
+
+	// TimeControl is nil for an untimed game
+	TimeControl *TimeControl `json:"timeControl,omitempty"`
+
+	// Clocks maps the index into the Player array to the milliseconds the player had left when TurnStartedAt was set
+	Clocks map[int]int64 `json:"clocks,omitempty"`
+
+	// TurnStartedAt is when the clock of the player at NextPlayerIdx started running, nil while no clock runs
+	TurnStartedAt *time.Time `json:"turnStartedAt,omitempty"`
+
+	// Variant is the set of rules the game is played by. Games stored before variants existed have none and are classic
+	Variant Variant `json:"variant,omitempty"`
+
+	// Layers is only set for the three dimensional variants, their GameBoard stacks the layers one below the other
+	Layers int `json:"layers,omitempty"`
+
+	// RuleSet decides whether completing a line wins or loses. Games stored before rule sets existed have none and are standard
+	RuleSet RuleSet `json:"ruleSet,omitempty"`
+
+	// Boards is only set for notakto, its GameBoard stacks the boards one below the other
+	Boards int `json:"boards,omitempty"`
+
+	// Roles maps the index into the Player array to the side each player takes, only set for order-chaos
+	Roles map[int]Role `json:"roles,omitempty"`
+
+	// Opening is the rule a gomoku game opens by, games without one open with any move
+	Opening OpeningRule `json:"opening,omitempty"`
+
+	// OpeningStage is where a swap or swap2 opening stands, empty once the colors are chosen and moves are played one by one
+	OpeningStage OpeningStage `json:"openingStage,omitempty"`
+
+	// Colors maps the index into the Player array to the color of the stones each player places, only set for games with an
+	// opening. Pro sets it from the start, swap and swap2 once the colors are chosen. The GameBoard then holds colors, not players
+	Colors map[int]Color `json:"colors,omitempty"`
+
+	// Numbers maps the index into the Player array to the numbers the player has left to place, only set for numerical
+	Numbers map[int][]int `json:"numbers,omitempty"`
+
+	// Quantum holds the spooky marks of a quantum game, nil for every other variant
+	Quantum *Quantum `json:"quantum,omitempty"`
+
+	// Ultimate holds the boards of an ultimate game, nil for every other variant. Its GameBoard stays empty
+	Ultimate *Ultimate `json:"ultimate,omitempty"`
+
+	// QuitPlayers holds the index into the Player array of every player who quit a game of more than two players
+	// The game goes on without them, and their turns are skipped
+	QuitPlayers []int `json:"quitPlayers,omitempty"`
+
+	// Gravity drops every piece to the lowest empty row of its column, like Connect Four. The last row is the bottom
+	Gravity bool `json:"gravity,omitempty"`
+
+	// PieRule lets the second player answer the first move by swapping sides, taking over its mark instead of moving
+	// The players then trade seats, along with their tokens and clocks
+	PieRule bool `json:"pieRule,omitempty"`
+
+	// Rated games count for the players' standing, so moves can never be taken back
+	Rated bool `json:"rated,omitempty"`
+
+	// PendingTakeback is a takeback waiting for the opponent's answer, nil when there is none
+	PendingTakeback *Takeback `json:"pendingTakeback,omitempty"`
+
+	// DrawReason is set when the game completed as a draw
+	DrawReason DrawReason `json:"drawReason,omitempty"`
+
+	// DrawOfferedBy is the index into the Player array of the player offering a draw, nil when no offer stands
+	DrawOfferedBy *int `json:"drawOfferedBy,omitempty"`
+}
+
+// Takeback is a player asking to undo the last Moves moves of the game
+type Takeback struct {
+	PlayerIdx int `json:"playerId"`
+	Moves     int `json:"moves"`
+}
+
+// TimeControl is either a total time per player with an increment added after each of their moves,
+// or a fixed limit for every move. Exactly one of InitialSeconds and MoveSeconds is set
+type TimeControl struct {
+	InitialSeconds   int `json:"initialSeconds,omitempty"`
+	IncrementSeconds int `json:"incrementSeconds,omitempty"`
+	MoveSeconds      int `json:"moveSeconds,omitempty"`
+}
+
+// Forfeit records who gave up a game and why
+type Forfeit struct {
+	Player string        `json:"player"`
+	Reason ForfeitReason `json:"reason"`
+}
+
+// Quantum is the state of a quantum game on top of its GameBoard
+type Quantum struct {
+
+	// Marks holds every mark in the order it was played, the subscript of a mark is its index plus one
+	Marks []SpookyMark `json:"marks"`
+
+	// PendingCollapse is the subscript of the mark that closed a cycle, nil while no collapse is awaited
+	PendingCollapse *int `json:"pendingCollapse,omitempty"`
+
+	// Scores maps the index into the Player array to the points scored, set once the game completed with a line
+	Scores map[int]float64 `json:"scores,omitempty"`
+}
+
+// Ultimate is the state of an ultimate game, a 3x3 grid of 3x3 boards
+type Ultimate struct {
+
+	// Boards holds every 3x3 board, numbered 0 to 8 in row major order. Each square is empty (-1) or holds a player_id
+	Boards [][][]int `json:"boards"`
+
+	// MetaBoard holds what became of every board: open (-1), won by a player_id, or drawn (2)
+	MetaBoard [][]int `json:"metaBoard"`
+
+	// ActiveBoard is the board the player to move has to play on, -1 when any open board will do
+	ActiveBoard int `json:"activeBoard"`
+}
+
+// SpookyMark is a mark of a quantum game, in superposition over both of its Squares until it collapses into one
+// The mark played into the last square left is classical from the start, both its Squares are that square
+type SpookyMark struct {
+	Player    int       `json:"player"`              // The index into the Player array of the player who placed it
+	Squares   [2]Square `json:"squares"`             // The two squares the mark was placed in
+	Collapsed *Square   `json:"collapsed,omitempty"` // The square the mark collapsed into, nil while it is in superposition
+}
+
+// Square is a square of the GameBoard
+type Square struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
 }
 
 // Move represents data about a TicTacToe move
 type Move struct {
-	Type   MoveType `json:"type"`
-	Player string   `json:"player"`
-	Row    int      `json:"row"`
-	Col    int      `json:"col"`
+	Type    MoveType `json:"type"`
+	Player  string   `json:"player"`
+	Row     int      `json:"row"`
+	Col     int      `json:"col"`
+	Layer   *int     `json:"layer,omitempty"`   // Only set in three dimensional games, Row is then the row within the layer
+	Board   *int     `json:"board,omitempty"`   // Only set in notakto and ultimate games, in notakto Row is then the row within the board
+	Cell    *int     `json:"cell,omitempty"`    // Only set in ultimate games, the cell within Board. Row and Col are then unused
+	Symbol  Symbol   `json:"symbol,omitempty"`  // Only set in wild and order-chaos games, the symbol Player placed
+	Squares []Square `json:"squares,omitempty"` // Only set for the spooky marks of quantum games, Row and Col are then unused
+	Mark    int      `json:"mark,omitempty"`    // Only set in quantum games, the subscript of the mark placed or collapsed
+	Value   int      `json:"value,omitempty"`   // Only set in numerical games, the number Player placed
+	Color   Color    `json:"color,omitempty"`   // Only set in games with an opening, the color of the stone placed or chosen
+}
+
+// Copy returns a deep copy of the game. The DB clients only hand out and store copies, so a caller
+// changing the board of a game it read can never change the stored game without writing it back
+// A new field holding a pointer, a slice or a map has to be copied here as well
+func (g Game) Copy() Game {
+
+	c := g
+	c.Winner = copyString(g.Winner)
+	c.GameBoard = copyBoard(g.GameBoard)
+	c.Forfeit = nil
+	if g.Forfeit != nil {
+		forfeit := *g.Forfeit
+		c.Forfeit = &forfeit
+	}
+	c.TimeControl = nil
+	if g.TimeControl != nil {
+		timeControl := *g.TimeControl
+		c.TimeControl = &timeControl
+	}
+	c.TurnStartedAt = nil
+	if g.TurnStartedAt != nil {
+		turnStartedAt := *g.TurnStartedAt
+		c.TurnStartedAt = &turnStartedAt
+	}
+	c.PendingTakeback = nil
+	if g.PendingTakeback != nil {
+		takeback := *g.PendingTakeback
+		c.PendingTakeback = &takeback
+	}
+	c.DrawOfferedBy = copyInt(g.DrawOfferedBy)
+	c.QuitPlayers = copyInts(g.QuitPlayers)
+
+	if g.Moves != nil {
+		c.Moves = make([]Move, len(g.Moves))
+		for i, move := range g.Moves {
+			c.Moves[i] = move.copy()
+		}
+	}
+
+	if g.Players != nil {
+		c.Players = make(map[int]string, len(g.Players))
+		for seat, player := range g.Players {
+			c.Players[seat] = player
+		}
+	}
+	if g.Bots != nil {
+		c.Bots = make(map[int]BotDifficulty, len(g.Bots))
+		for seat, difficulty := range g.Bots {
+			c.Bots[seat] = difficulty
+		}
+	}
+	if g.TokenHashes != nil {
+		c.TokenHashes = make(map[int]string, len(g.TokenHashes))
+		for seat, hash := range g.TokenHashes {
+			c.TokenHashes[seat] = hash
+		}
+	}
+	if g.Clocks != nil {
+		c.Clocks = make(map[int]int64, len(g.Clocks))
+		for seat, clock := range g.Clocks {
+			c.Clocks[seat] = clock
+		}
+	}
+	if g.Roles != nil {
+		c.Roles = make(map[int]Role, len(g.Roles))
+		for seat, role := range g.Roles {
+			c.Roles[seat] = role
+		}
+	}
+	if g.Colors != nil {
+		c.Colors = make(map[int]Color, len(g.Colors))
+		for seat, color := range g.Colors {
+			c.Colors[seat] = color
+		}
+	}
+	if g.Numbers != nil {
+		c.Numbers = make(map[int][]int, len(g.Numbers))
+		for seat, numbers := range g.Numbers {
+			c.Numbers[seat] = copyInts(numbers)
+		}
+	}
+
+	if g.Quantum != nil {
+		quantum := Quantum{PendingCollapse: copyInt(g.Quantum.PendingCollapse)}
+		if g.Quantum.Marks != nil {
+			quantum.Marks = make([]SpookyMark, len(g.Quantum.Marks))
+			for i, mark := range g.Quantum.Marks {
+				quantum.Marks[i] = mark
+				if mark.Collapsed != nil {
+					collapsed := *mark.Collapsed
+					quantum.Marks[i].Collapsed = &collapsed
+				}
+			}
+		}
+		if g.Quantum.Scores != nil {
+			quantum.Scores = make(map[int]float64, len(g.Quantum.Scores))
+			for seat, score := range g.Quantum.Scores {
+				quantum.Scores[seat] = score
+			}
+		}
+		c.Quantum = &quantum
+	}
+
+	if g.Ultimate != nil {
+		ultimate := Ultimate{MetaBoard: copyBoard(g.Ultimate.MetaBoard), ActiveBoard: g.Ultimate.ActiveBoard}
+		if g.Ultimate.Boards != nil {
+			ultimate.Boards = make([][][]int, len(g.Ultimate.Boards))
+			for i, board := range g.Ultimate.Boards {
+				ultimate.Boards[i] = copyBoard(board)
+			}
+		}
+		c.Ultimate = &ultimate
+	}
+
+	return c
+}
+
+// copy returns a deep copy of the move
+func (m Move) copy() Move {
+
+	c := m
+	c.Layer = copyInt(m.Layer)
+	c.Board = copyInt(m.Board)
+	c.Cell = copyInt(m.Cell)
+	if m.Squares != nil {
+		c.Squares = append([]Square{}, m.Squares...)
+	}
+
+	return c
+}
+
+func copyBoard(board [][]int) [][]int {
+
+	if board == nil {
+		return nil
+	}
+
+	c := make([][]int, len(board))
+	for i, row := range board {
+		c[i] = copyInts(row)
+	}
+
+	return c
+}
+
+func copyInts(values []int) []int {
+
+	if values == nil {
+		return nil
+	}
+
+	return append([]int{}, values...)
+}
+
+func copyInt(value *int) *int {
+
+	if value == nil {
+		return nil
+	}
+
+	c := *value
+	return &c
+}
+
+func copyString(value *string) *string {
+
+	if value == nil {
+		return nil
+	}
+
+	c := *value
+	return &c
 }
 
 // New returns a new Client to access the DB
@@ -100,7 +534,7 @@ func (c *Client) GetGameWithID(id string) (Game, error) {
 		return Game{}, fmt.Errorf("No game exists with provided game_id %s", id)
 	}
 
-	return game, nil
+	return game.Copy(), nil
 }
 
 // GetAllGames returns a list of all TicTacToe games listed in the DB table
@@ -112,7 +546,7 @@ func (c *Client) GetAllGames() ([]Game, error) {
 	defer func() { c.channelLock <- true }()
 
 	for _, game := range ticTacToeDbTable {
-		result = append(result, game)
+		result = append(result, game.Copy())
 	}
 
 	return result, nil
@@ -124,24 +558,47 @@ func (c *Client) CreateNewGame(game Game) (string, error) {
 	<-c.channelLock
 	defer func() { c.channelLock <- true }()
 
-	ticTacToeDbTable[game.ID] = game
+	ticTacToeDbTable[game.ID] = game.Copy()
 
 	return game.ID, nil
 }
 
-// UpdateGame updates an existing game
+// UpdateGame updates an existing game, no matter if it changed since the caller read it
 func (c *Client) UpdateGame(game Game) error {
 
 	<-c.channelLock
 	defer func() { c.channelLock <- true }()
 
-	_, ok := ticTacToeDbTable[game.ID]
+	stored, ok := ticTacToeDbTable[game.ID]
 
 	if !ok {
 		// This state should never be reached since the caller SHOULD call the GetGameWithID method first
 		return fmt.Errorf("Failed to Update game. Game with game_id %s does not exist", game.ID)
 	}
 
-	ticTacToeDbTable[game.ID] = game
+	game.Version = stored.Version + 1
+	ticTacToeDbTable[game.ID] = game.Copy()
+	return nil
+}
+
+// CompareAndSwapGame updates an existing game only if its version is still the one the caller read
+// Returns ErrVersionConflict when another update got there first, the caller should read the game again and retry
+func (c *Client) CompareAndSwapGame(game Game) error {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	stored, ok := ticTacToeDbTable[game.ID]
+
+	if !ok {
+		return fmt.Errorf("Failed to Update game. Game with game_id %s does not exist", game.ID)
+	}
+
+	if stored.Version != game.Version {
+		return ErrVersionConflict
+	}
+
+	game.Version++
+	ticTacToeDbTable[game.ID] = game.Copy()
 	return nil
 }
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A test file for only database.go
+
+func TestGameCopyIsDeep(t *testing.T) {
+
+	winner := "player1"
+	offeredBy := 0
+	board, cell := 4, 0
+	startedAt := time.Now()
+	game := generateGame("gameID1")
+	game.Winner = &winner
+	game.DrawOfferedBy = &offeredBy
+	game.TurnStartedAt = &startedAt
+	game.Clocks = map[int]int64{0: 30000, 1: 30000}
+	game.Numbers = map[int][]int{0: {1, 3}, 1: {2, 4}}
+	game.Moves = []Move{{Type: MoveTypeMove, Player: "player1", Board: &board, Cell: &cell}}
+	game.Quantum = &Quantum{Marks: []SpookyMark{{Player: 0, Collapsed: &Square{Row: 1, Col: 1}}}}
+	game.Ultimate = &Ultimate{Boards: [][][]int{{{-1, -1, -1}}}, MetaBoard: [][]int{{-1, -1, -1}}}
+
+	copied := game.Copy()
+	assert.Equal(t, game, copied)
+
+	// nothing the copy points to is shared with the game
+	copied.GameBoard[0][0] = 1
+	*copied.Winner = "player2"
+	*copied.DrawOfferedBy = 1
+	copied.Clocks[0] = 0
+	copied.Numbers[0][0] = 5
+	*copied.Moves[0].Cell = 8
+	copied.Quantum.Marks[0].Collapsed.Row = 2
+	copied.Ultimate.Boards[0][0][0] = 0
+	copied.Ultimate.MetaBoard[0][0] = 0
+
+	assert.Equal(t, -1, game.GameBoard[0][0])
+	assert.Equal(t, "player1", *game.Winner)
+	assert.Equal(t, 0, *game.DrawOfferedBy)
+	assert.Equal(t, int64(30000), game.Clocks[0])
+	assert.Equal(t, 1, game.Numbers[0][0])
+	assert.Equal(t, 0, *game.Moves[0].Cell)
+	assert.Equal(t, 1, game.Quantum.Marks[0].Collapsed.Row)
+	assert.Equal(t, -1, game.Ultimate.Boards[0][0][0])
+	assert.Equal(t, -1, game.Ultimate.MetaBoard[0][0])
+}
@@ -0,0 +1,347 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+/*
+	FileClient is a durable implementation of the DB interface that keeps its table in a local directory
+	Every write is appended to a write-ahead log (games.wal) and synced to disk before the caller is answered.
+	Once the log holds snapshotEvery records the whole table is compacted into snapshot.json and the log starts over.
+	On startup the snapshot is loaded and the log replayed on top of it, so a crash loses nothing that was acknowledged.
+	A record torn by a crash halfway through a write was never acknowledged, it is dropped during recovery.
+	A write that fails is cut back out of the log, and if that fails too the client refuses every write after it,
+	so an acknowledged record is never appended after a broken one
+*/
+
+const (
+	snapshotFileName = "snapshot.json"
+	walFileName      = "games.wal"
+
+	// defaultSnapshotEvery is how many log records are written before the table is compacted into a snapshot
+	defaultSnapshotEvery = 100
+)
+
+// FileClient is the client that implements the DB interface on top of a data directory
+type FileClient struct {
+
+	// a channel for locking the table and the files to simulate atomic read and writes
+	channelLock chan bool
+
+	dir           string
+	table         map[string]Game
+	wal           *os.File
+	walRecords    int
+	snapshotEvery int
+
+	// walSize is the end of the last record fully written to the log, where the next one starts
+	walSize int64
+
+	// broken is set once the log could not be repaired after a failed write, no write succeeds after it
+	broken error
+}
+
+// Verifying if the FileClient struct is indeed implementing the DB interface
+var _ DB = (*FileClient)(nil)
+
+// walRecord is a single line of the write-ahead log, holding the full game as it was written
+type walRecord struct {
+	Game Game `json:"game"`
+}
+
+// NewFileClient opens the data directory, recovering any games stored there, and returns a Client to access the DB
+func NewFileClient(dir string) (*FileClient, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create data directory %s. %s", dir, err.Error())
+	}
+
+	c := make(chan bool, 1)
+	c <- true
+	client := &FileClient{
+		channelLock:   c,
+		dir:           dir,
+		table:         map[string]Game{},
+		snapshotEvery: defaultSnapshotEvery,
+	}
+
+	if err := client.recover(); err != nil {
+		return nil, err
+	}
+
+	// start from a clean snapshot and an empty log, so a torn record from the last run is gone for good
+	if err := client.compact(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// GetGameWithID  returns a game from the DB provided the game id
+// return an error if no game with the provided id exists
+func (c *FileClient) GetGameWithID(id string) (Game, error) {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	game, ok := c.table[id]
+	if !ok {
+		return Game{}, fmt.Errorf("No game exists with provided game_id %s", id)
+	}
+
+	return game.Copy(), nil
+}
+
+// GetAllGames returns a list of all TicTacToe games listed in the DB table
+func (c *FileClient) GetAllGames() ([]Game, error) {
+
+	result := []Game{}
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	for _, game := range c.table {
+		result = append(result, game.Copy())
+	}
+
+	return result, nil
+}
+
+// CreateNewGame creates a new game submitted by the caller as a row in the DB, return the gameID provided
+func (c *FileClient) CreateNewGame(game Game) (string, error) {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	if err := c.write(game); err != nil {
+		return "", err
+	}
+
+	return game.ID, nil
+}
+
+// UpdateGame updates an existing game, no matter if it changed since the caller read it
+func (c *FileClient) UpdateGame(game Game) error {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	stored, ok := c.table[game.ID]
+
+	if !ok {
+		// This state should never be reached since the caller SHOULD call the GetGameWithID method first
+		return fmt.Errorf("Failed to Update game. Game with game_id %s does not exist", game.ID)
+	}
+
+	game.Version = stored.Version + 1
+	return c.write(game)
+}
+
+// CompareAndSwapGame updates an existing game only if its version is still the one the caller read
+// Returns ErrVersionConflict when another update got there first, the caller should read the game again and retry
+func (c *FileClient) CompareAndSwapGame(game Game) error {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	stored, ok := c.table[game.ID]
+
+	if !ok {
+		return fmt.Errorf("Failed to Update game. Game with game_id %s does not exist", game.ID)
+	}
+
+	if stored.Version != game.Version {
+		return ErrVersionConflict
+	}
+
+	game.Version++
+	return c.write(game)
+}
+
+// Close compacts the table into a final snapshot and releases the log
+func (c *FileClient) Close() error {
+
+	<-c.channelLock
+	defer func() { c.channelLock <- true }()
+
+	if err := c.compact(); err != nil {
+		return err
+	}
+
+	return c.wal.Close()
+}
+
+// write appends the game to the log and only then stores it in the table. The caller must hold the channelLock
+func (c *FileClient) write(game Game) error {
+
+	if c.broken != nil {
+		return fmt.Errorf("Failed to write game %s, the log is broken. %s", game.ID, c.broken.Error())
+	}
+
+	line, err := json.Marshal(walRecord{Game: game})
+	if err != nil {
+		return fmt.Errorf("Failed to encode game %s. %s", game.ID, err.Error())
+	}
+	line = append(line, '\n')
+
+	if _, err := c.wal.Write(line); err != nil {
+		return c.truncateLog(fmt.Errorf("Failed to write game %s to the log. %s", game.ID, err.Error()))
+	}
+
+	if err := c.wal.Sync(); err != nil {
+		return c.truncateLog(fmt.Errorf("Failed to sync game %s to disk. %s", game.ID, err.Error()))
+	}
+
+	c.table[game.ID] = game.Copy()
+	c.walRecords++
+	c.walSize += int64(len(line))
+
+	if c.walRecords >= c.snapshotEvery {
+		// the game is already safe in the log, a failed compaction only means the log keeps growing
+		if err := c.compact(); err != nil {
+			log.Printf("Failed to compact the DB: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// truncateLog cuts whatever the write that failed with writeErr left in the log back to the last record fully written,
+// and returns writeErr along with the reason the client is broken when that fails as well. The caller must hold the channelLock
+func (c *FileClient) truncateLog(writeErr error) error {
+
+	if err := c.wal.Truncate(c.walSize); err != nil {
+		c.broken = fmt.Errorf("Failed to truncate the log. %s", err.Error())
+	} else if err := c.wal.Sync(); err != nil {
+		c.broken = fmt.Errorf("Failed to sync the truncated log. %s", err.Error())
+	}
+
+	if c.broken != nil {
+		return fmt.Errorf("%s %s", writeErr.Error(), c.broken.Error())
+	}
+
+	return writeErr
+}
+
+// recover loads the last snapshot and replays the log written after it
+func (c *FileClient) recover() error {
+
+	snapshot, err := ioutil.ReadFile(filepath.Join(c.dir, snapshotFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to read snapshot. %s", err.Error())
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(snapshot, &c.table); err != nil {
+			return fmt.Errorf("Failed to decode snapshot. %s", err.Error())
+		}
+	}
+
+	wal, err := ioutil.ReadFile(filepath.Join(c.dir, walFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read the log. %s", err.Error())
+	}
+
+	lines := bytes.Split(bytes.TrimRight(wal, "\n"), []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+
+		record := walRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			// a torn record can only be the last one, anything unreadable before it means records were lost
+			if i < len(lines)-1 {
+				return fmt.Errorf("Failed to replay the log, record %d is corrupt. %s", i, err.Error())
+			}
+
+			log.Printf("Dropping a torn record at the end of the log: %s", err.Error())
+			break
+		}
+		c.table[record.Game.ID] = record.Game
+	}
+
+	return nil
+}
+
+// compact writes the whole table to a new snapshot and starts an empty log. The caller must hold the channelLock
+func (c *FileClient) compact() error {
+
+	snapshot, err := json.Marshal(c.table)
+	if err != nil {
+		return fmt.Errorf("Failed to encode snapshot. %s", err.Error())
+	}
+
+	// write the snapshot beside the old one and swap it in, so a crash never leaves a half written snapshot
+	tmpPath := filepath.Join(c.dir, snapshotFileName+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("Failed to create snapshot. %s", err.Error())
+	}
+
+	if _, err := tmp.Write(snapshot); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write snapshot. %s", err.Error())
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to sync snapshot. %s", err.Error())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to close snapshot. %s", err.Error())
+	}
+
+	if err := os.Rename(tmpPath, filepath.Join(c.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("Failed to replace snapshot. %s", err.Error())
+	}
+
+	// the rename only survives a crash once the directory itself is synced, the log must not be emptied before that
+	if err := syncDir(c.dir); err != nil {
+		return err
+	}
+
+	// every record in the log is now part of the snapshot, replaying it again after a crash here is harmless
+	// The old log stays open until the new one is, so a failed open leaves a working log to retry the compaction from
+	wal, err := os.OpenFile(filepath.Join(c.dir, walFileName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open the log. %s", err.Error())
+	}
+
+	if c.wal != nil {
+		c.wal.Close()
+	}
+
+	c.wal = wal
+	c.walRecords = 0
+	c.walSize = 0
+	c.broken = nil
+
+	return nil
+}
+
+// syncDir flushes the entries of dir to disk, so files created or renamed in it are there after a crash
+func syncDir(dir string) error {
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("Failed to open data directory %s. %s", dir, err.Error())
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync data directory %s. %s", dir, err.Error())
+	}
+
+	return nil
+}
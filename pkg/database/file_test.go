@@ -0,0 +1,271 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileClientSurvivesRestart(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+
+	game := generateGame("gameID1")
+	_, err = client.CreateNewGame(game)
+	assert.NoError(t, err)
+
+	game.State = StateComplete
+	assert.NoError(t, client.UpdateGame(game))
+
+	// simulate a crash: the log is never compacted and the process simply goes away
+	recovered, err := NewFileClient(dir)
+	assert.NoError(t, err)
+
+	stored, err := recovered.GetGameWithID("gameID1")
+	assert.NoError(t, err)
+	assert.Equal(t, StateComplete, stored.State)
+	assert.NoError(t, recovered.Close())
+}
+
+func TestFileClientDropsTornRecord(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.NoError(t, err)
+
+	// a write cut off halfway by a crash
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = wal.WriteString(`{"game":{"id":"gameID2","play`)
+	assert.NoError(t, err)
+	wal.Close()
+
+	recovered, err := NewFileClient(dir)
+	assert.NoError(t, err)
+
+	games, err := recovered.GetAllGames()
+	assert.NoError(t, err)
+	assert.Len(t, games, 1)
+	assert.Equal(t, "gameID1", games[0].ID)
+	assert.NoError(t, recovered.Close())
+}
+
+func TestFileClientRejectsCorruptLog(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.NoError(t, err)
+
+	// a fragment followed by a record that was acknowledged, the fragment is no torn end of the log
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = wal.WriteString(`{"game":{"id":"gameID2","play` + "\n")
+	assert.NoError(t, err)
+	wal.Close()
+	_, err = client.CreateNewGame(generateGame("gameID3"))
+	assert.NoError(t, err)
+
+	_, err = NewFileClient(dir)
+	assert.Error(t, err)
+}
+
+func TestFileClientTruncatesFailedWrite(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.NoError(t, err)
+
+	// a write cut short by a full disk is cut back out, and the next record starts on a line of its own
+	_, err = client.wal.WriteString(`{"game":{"id":"gameID2","play`)
+	assert.NoError(t, err)
+	writeErr := errors.New("no space left on device")
+	assert.Equal(t, writeErr, client.truncateLog(writeErr))
+	assert.NoError(t, client.broken)
+
+	_, err = client.CreateNewGame(generateGame("gameID3"))
+	assert.NoError(t, err)
+
+	recovered, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	games, err := recovered.GetAllGames()
+	assert.NoError(t, err)
+	assert.Len(t, games, 2)
+	assert.NoError(t, recovered.Close())
+}
+
+func TestFileClientBreaksWhenLogCanNotBeRepaired(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+
+	// neither the write nor the truncation can reach the log any more
+	client.wal.Close()
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.Error(t, err)
+	assert.Error(t, client.broken)
+
+	_, err = client.GetGameWithID("gameID1")
+	assert.Error(t, err)
+}
+
+func TestFileClientKeepsLogWhenCompactionCanNotReopenIt(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	client.snapshotEvery = 1
+
+	// a directory in place of the log makes opening a new one fail, the old one has to stay usable
+	walPath := filepath.Join(dir, walFileName)
+	assert.NoError(t, os.Remove(walPath))
+	assert.NoError(t, os.Mkdir(walPath, 0755))
+
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.NoError(t, err)
+	_, err = client.CreateNewGame(generateGame("gameID2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.walRecords)
+
+	// the next write retries the compaction, and starts a new log once it can
+	assert.NoError(t, os.Remove(walPath))
+	_, err = client.CreateNewGame(generateGame("gameID3"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, client.walRecords)
+	assert.NoError(t, client.Close())
+
+	recovered, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	games, err := recovered.GetAllGames()
+	assert.NoError(t, err)
+	assert.Len(t, games, 3)
+	assert.NoError(t, recovered.Close())
+}
+
+func TestFileClientCompactsLog(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	client.snapshotEvery = 3
+
+	game := generateGame("gameID1")
+	_, err = client.CreateNewGame(game)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		game.Moves = append(game.Moves, Move{Type: MoveTypeMove, Player: "player1", Row: i, Col: i})
+		assert.NoError(t, client.UpdateGame(game))
+	}
+
+	// three records were compacted into the snapshot, one is left in the log
+	wal, err := ioutil.ReadFile(filepath.Join(dir, walFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(wal, []byte("\n")))
+
+	recovered, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	stored, err := recovered.GetGameWithID("gameID1")
+	assert.NoError(t, err)
+	assert.Len(t, stored.Moves, 3)
+	assert.NoError(t, recovered.Close())
+}
+
+func TestFileClientRejectsStaleWrite(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+	_, err = client.CreateNewGame(generateGame("gameID1"))
+	assert.NoError(t, err)
+
+	// two requests read the same version of the game
+	first, _ := client.GetGameWithID("gameID1")
+	second, _ := client.GetGameWithID("gameID1")
+
+	first.NextPlayerIdx = 1
+	assert.NoError(t, client.CompareAndSwapGame(first))
+
+	second.NextPlayerIdx = 0
+	assert.Equal(t, ErrVersionConflict, client.CompareAndSwapGame(second))
+
+	stored, _ := client.GetGameWithID("gameID1")
+	assert.Equal(t, 1, stored.NextPlayerIdx)
+	assert.Equal(t, 1, stored.Version)
+	assert.NoError(t, client.Close())
+}
+
+func TestFileClientRejectsGameItCanNotEncode(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tictactoe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewFileClient(dir)
+	assert.NoError(t, err)
+
+	// a score that is not a number can not be written to the log, the client refuses the game
+	game := generateGame("gameID1")
+	game.Quantum = &Quantum{Scores: map[int]float64{0: math.NaN()}}
+	_, err = client.CreateNewGame(game)
+	assert.Error(t, err)
+
+	_, err = client.GetGameWithID("gameID1")
+	assert.Error(t, err)
+
+	// the refused game never reached the log, so the client keeps working
+	_, err = client.CreateNewGame(generateGame("gameID2"))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+}
+
+func generateGame(id string) Game {
+	return Game{
+		ID:            id,
+		Players:       map[int]string{0: "player1", 1: "player2"},
+		Columns:       3,
+		Rows:          3,
+		WinLength:     3,
+		State:         StateInProgress,
+		Moves:         []Move{},
+		NextPlayerIdx: -1,
+		GameBoard:     [][]int{{-1, -1, -1}, {-1, -1, -1}, {-1, -1, -1}},
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+// Result is the outcome of a position or a move for the player to move
+type Result string
+
+const (
+	ResultWin     Result = "WIN"
+	ResultDraw    Result = "DRAW"
+	ResultLoss    Result = "LOSS"
+	ResultUnknown Result = "UNKNOWN"
+)
+
+// SquareEvaluation is the engine's verdict on playing a single square
+type SquareEvaluation struct {
+	Square
+
+	// Result is the outcome for the player making the move with best play from both sides
+	Result Result `json:"result"`
+
+	// Distance is the number of moves, this one included, until the result is reached. nil when the result is UNKNOWN or a DRAW
+	Distance *int `json:"distance"`
+}
+
+// Analysis is the engine's verdict on a whole position
+type Analysis struct {
+	// Result is the outcome for the player to move with best play from both sides
+	Result Result `json:"result"`
+
+	// Decided is true when the result is known for certain, either by solving the position or by finding a forced win
+	Decided bool `json:"decided"`
+
+	// BestMoves holds every square sharing the best evaluation
+	BestMoves []Square `json:"bestMoves"`
+
+	// Evaluations holds every legal square in row major order
+	Evaluations []SquareEvaluation `json:"evaluations"`
+}
+
+// Analyze evaluates every legal move in the position for the player to move
+// Boards small enough are solved, so every result is exact. Larger boards use the heuristic search, which can only
+// see wins and losses a couple of moves ahead and otherwise reports UNKNOWN. Positions of more than two marks are refused
+func Analyze(p Position) (Analysis, error) {
+
+	if err := p.checkTwoMarks(); err != nil {
+		return Analysis{}, err
+	}
+
+	scored, exact := scoreMoves(p)
+
+	scores := map[Square]int{}
+	for _, s := range scored {
+		scores[s.square] = s.score
+	}
+
+	analysis := Analysis{Result: ResultUnknown, BestMoves: []Square{}, Evaluations: []SquareEvaluation{}}
+	bestScore := 0
+	for _, m := range p.LegalMoves() {
+		score, searched := scores[m]
+		if !searched {
+			// the heuristic search did not consider this square worth looking at
+			analysis.Evaluations = append(analysis.Evaluations, SquareEvaluation{Square: m, Result: ResultUnknown})
+			continue
+		}
+
+		analysis.Evaluations = append(analysis.Evaluations, evaluateScore(m, score, exact))
+		if len(analysis.BestMoves) == 0 || score > bestScore {
+			analysis.BestMoves = []Square{m}
+			bestScore = score
+		} else if score == bestScore {
+			analysis.BestMoves = append(analysis.BestMoves, m)
+		}
+	}
+
+	if len(analysis.BestMoves) == 0 {
+		return analysis, nil
+	}
+
+	best := evaluateScore(analysis.BestMoves[0], bestScore, exact)
+	switch {
+	case exact:
+		analysis.Result = best.Result
+		analysis.Decided = true
+	case best.Result == ResultWin:
+		analysis.Result = ResultWin
+		analysis.Decided = true
+	case best.Result == ResultLoss && len(scored) == len(p.LegalMoves()):
+		// every legal square was searched and they all lose
+		analysis.Result = ResultLoss
+		analysis.Decided = true
+	}
+
+	return analysis, nil
+}
+
+// evaluateScore translates a search score into a result and a distance to that result
+func evaluateScore(square Square, score int, exact bool) SquareEvaluation {
+
+	evaluation := SquareEvaluation{Square: square, Result: ResultUnknown}
+
+	magnitude := score
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+
+	switch {
+	case exact && score == 0:
+		evaluation.Result = ResultDraw
+		return evaluation
+	case exact && score > 0, !exact && score >= heuristicWin:
+		evaluation.Result = ResultWin
+	case exact && score < 0, !exact && score <= -heuristicWin:
+		evaluation.Result = ResultLoss
+	default:
+		return evaluation
+	}
+
+	// an exact score counts down from solvedWin per ply, a heuristic win counts up with the depth left when it was found
+	distance := solvedWin - magnitude
+	if !exact {
+		distance = heuristicDepth + 1 - (magnitude - heuristicWin)
+	}
+	evaluation.Distance = &distance
+
+	return evaluation
+}
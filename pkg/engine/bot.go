@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"math/rand"
+)
+
+// RandomMove picks any legal move. The position must have at least one empty square
+func RandomMove(p Position, rng *rand.Rand) Square {
+
+	moves := p.LegalMoves()
+	return moves[rng.Intn(len(moves))]
+}
+
+// EasyMove wins when it can and blocks an immediate loss, but otherwise plays a random move
+// When completing a line loses it only steers clear of the moves that lose straight away. Like BestMove it only plays two marks
+func EasyMove(p Position, rng *rand.Rand) (Square, error) {
+
+	if err := p.checkTwoMarks(); err != nil {
+		return Square{}, err
+	}
+
+	board := copyBoard(p.Board)
+	moves := p.LegalMoves()
+
+	if p.Rules != RulesStandard {
+		safe := []Square{}
+		for _, m := range moves {
+			board[m.Row][m.Column] = p.ToMove
+			outcome := p.rules().outcome(board, m.Row, m.Column)
+			board[m.Row][m.Column] = Empty
+			if outcome != OutcomeLoss {
+				safe = append(safe, m)
+			}
+		}
+		if len(safe) > 0 {
+			return safe[rng.Intn(len(safe))], nil
+		}
+		return moves[rng.Intn(len(moves))], nil
+	}
+
+	// take a win first, then block the opponent's win
+	for _, mark := range []int{p.ToMove, 1 - p.ToMove} {
+		for _, m := range moves {
+			board[m.Row][m.Column] = mark
+			wins := IsWinningMove(board, m.Row, m.Column, p.WinLength)
+			board[m.Row][m.Column] = Empty
+			if wins {
+				return m, nil
+			}
+		}
+	}
+
+	return moves[rng.Intn(len(moves))], nil
+}
+
+// BestMove plays perfectly on boards small enough to solve, and uses the heuristic search on larger boards
+// Ties between equally good moves are broken at random so the computer does not always play the same game.
+// Positions of more than two marks are refused, the search only knows how to alternate between two
+func BestMove(p Position, rng *rand.Rand) (Square, error) {
+
+	if err := p.checkTwoMarks(); err != nil {
+		return Square{}, err
+	}
+
+	scored, _ := scoreMoves(p)
+
+	best := []Square{}
+	bestScore := 0
+	for _, s := range scored {
+		if len(best) == 0 || s.score > bestScore {
+			best = []Square{s.square}
+			bestScore = s.score
+		} else if s.score == bestScore {
+			best = append(best, s.square)
+		}
+	}
+
+	return best[rng.Intn(len(best))], nil
+}
@@ -0,0 +1,248 @@
+package engine
+
+/*
+	The engine holds the TicTacToe rules and the search used by the computer opponent
+	It only knows about boards, so any endpoint that needs to reason about a position can share it
+	without caring about how a game is stored in the DB
+*/
+
+// Empty is the value of a square that has not been played yet
+const Empty = -1
+
+// Square addresses a single square on the board
+type Square struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// Position is a snapshot of a board that the engine can search from
+type Position struct {
+	// Board holds Empty for open squares, otherwise the mark (0 or 1) of the player owning the square
+	Board [][]int
+
+	// WinLength is the number of consecutive squares needed to win
+	WinLength int
+
+	// ToMove is the mark of the player whose turn it is
+	ToMove int
+
+	// Gravity drops every mark to the lowest empty square of its column, like Connect Four
+	Gravity bool
+
+	// Rules decides whether completing a line wins or loses
+	Rules RuleSet
+
+	// Boards is the number of notakto boards stacked in Board, each with an equal share of its rows
+	Boards int
+
+	// TurnOrder holds the marks still playing in the order they move, starting with ToMove
+	// It is only needed for games of more than two players, nil means the two marks take turns
+	TurnOrder []int
+}
+
+// lineDirections are the steps along which a line can run
+var lineDirections = [][2]int{
+	{0, 1},  // Left to Right
+	{1, 0},  // Up and Down
+	{1, 1},  // TopLeft to BottomRight Diagonal
+	{1, -1}, // TopRight to BottomLeft Diagonal
+}
+
+// IsWinningMove checks if the mark placed at row, col completed a line of winLength squares
+// Only the row, the column and the two diagonals running through the move can have been completed by it
+func IsWinningMove(board [][]int, row, col, winLength int) bool {
+
+	mark := board[row][col]
+	if mark == Empty {
+		return false
+	}
+
+	// each direction is walked both forwards and backwards from the move
+	for _, d := range lineDirections {
+		// the move itself counts as the first square of the line
+		squareCount := 1
+		squareCount += countSquaresInDirection(board, row, col, d[0], d[1], mark)
+		squareCount += countSquaresInDirection(board, row, col, -d[0], -d[1], mark)
+
+		if squareCount >= winLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countSquaresInDirection counts the consecutive squares holding mark starting next to row, col
+// and stepping by rowStep, colStep until the line is broken or the edge of the board is reached
+func countSquaresInDirection(board [][]int, row, col, rowStep, colStep, mark int) int {
+
+	count := 0
+	r := row + rowStep
+	c := col + colStep
+	for r >= 0 && r < len(board) && c >= 0 && c < len(board[r]) {
+		if board[r][c] != mark {
+			break
+		}
+		count++
+		r += rowStep
+		c += colStep
+	}
+
+	return count
+}
+
+// IsFull returns true when there are no empty squares left on the board
+func IsFull(board [][]int) bool {
+
+	for _, row := range board {
+		for _, square := range row {
+			if square == Empty {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CanStillBeWon returns true while some line of WinLength squares holds the marks of only one player, and that player
+// has enough moves left to fill the rest of it. Once it returns false the game can only end in a draw
+func (p Position) CanStillBeWon() bool {
+
+	// every mark counts for both players in notakto, so somebody always ends up completing a line
+	if p.Rules == RulesNotakto {
+		return true
+	}
+
+	turnOrder := p.TurnOrder
+	if len(turnOrder) == 0 {
+		turnOrder = []int{p.ToMove, 1 - p.ToMove}
+	}
+
+	return p.hasOpenLine(movesLeft(p.Board, turnOrder))
+}
+
+// hasOpenLine returns true if some line of WinLength squares can still be filled with a single mark, see lineIsOpen
+func (p Position) hasOpenLine(movesLeft map[int]int) bool {
+
+	for r, row := range p.Board {
+		for c := range row {
+			for _, d := range lineDirections {
+				endRow := r + (p.WinLength-1)*d[0]
+				endCol := c + (p.WinLength-1)*d[1]
+				if endRow >= len(p.Board) || endCol < 0 || endCol >= len(row) {
+					continue
+				}
+
+				squares := []int{}
+				for i := 0; i < p.WinLength; i++ {
+					squares = append(squares, p.Board[r+i*d[0]][c+i*d[1]])
+				}
+				if lineIsOpen(squares, movesLeft) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// movesLeft returns how many more moves each mark gets on the board when the marks of turnOrder keep taking turns
+// The marks first in line get one more move when the squares left do not share out evenly
+func movesLeft(board [][]int, turnOrder []int) map[int]int {
+
+	empties := emptySquares(board)
+
+	left := map[int]int{}
+	for i, mark := range turnOrder {
+		left[mark] = empties / len(turnOrder)
+		if i < empties%len(turnOrder) {
+			left[mark]++
+		}
+	}
+
+	return left
+}
+
+// lineIsOpen returns true if the squares of a line hold the marks of at most one player,
+// and that player has enough moves left to fill the rest of the line
+func lineIsOpen(squares []int, movesLeft map[int]int) bool {
+
+	owner := Empty
+	open := 0
+	for _, square := range squares {
+		if square == Empty {
+			open++
+		} else if owner == Empty {
+			owner = square
+		} else if square != owner {
+			return false
+		}
+	}
+
+	if owner == Empty {
+		for _, left := range movesLeft {
+			if left >= open {
+				return true
+			}
+		}
+		return false
+	}
+
+	return movesLeft[owner] >= open
+}
+
+// LegalMoves returns every square the player to move may play, in row major order
+func (p Position) LegalMoves() []Square {
+	return p.rules().legalMoves(p.Board)
+}
+
+// DropRow returns the lowest empty row of column col, where a mark dropped into it lands, or -1 when the column is full
+// The last row of the board is its bottom
+func DropRow(board [][]int, col int) int {
+
+	for r := len(board) - 1; r >= 0; r-- {
+		if board[r][col] == Empty {
+			return r
+		}
+	}
+
+	return -1
+}
+
+// isPlayable returns true if r, c is empty and, with gravity, sits on the bottom row or on top of a played square
+func isPlayable(board [][]int, r, c int, gravity bool) bool {
+
+	if board[r][c] != Empty {
+		return false
+	}
+
+	return !gravity || r == len(board)-1 || board[r+1][c] != Empty
+}
+
+// emptySquares counts the squares left to play
+func emptySquares(board [][]int) int {
+
+	count := 0
+	for _, row := range board {
+		for _, square := range row {
+			if square == Empty {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// copyBoard returns a deep copy of board so a search never modifies the caller's game
+func copyBoard(board [][]int) [][]int {
+
+	result := make([][]int, len(board))
+	for i, row := range board {
+		result[i] = append([]int{}, row...)
+	}
+
+	return result
+}
@@ -0,0 +1,246 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWinningMove(t *testing.T) {
+
+	board := newBoard(3, 3)
+	board[0][2] = 1
+	board[1][1] = 1
+	assert.False(t, IsWinningMove(board, 1, 1, 3))
+
+	board[2][0] = 1
+	assert.True(t, IsWinningMove(board, 2, 0, 3))
+	assert.False(t, IsWinningMove(board, 0, 0, 3))
+}
+
+func TestIsFull(t *testing.T) {
+
+	board := newBoard(2, 3)
+	assert.False(t, IsFull(board))
+
+	for r := range board {
+		for c := range board[r] {
+			board[r][c] = (r + c) % 2
+		}
+	}
+	assert.True(t, IsFull(board))
+}
+
+func TestCanStillBeWon(t *testing.T) {
+
+	// every line holds both marks
+	board := [][]int{
+		{0, 1, 0},
+		{0, 1, 1},
+		{1, 0, Empty},
+	}
+	assert.False(t, Position{Board: board, WinLength: 3, ToMove: 0}.CanStillBeWon())
+
+	// opening up the right column gives O the anti diagonal back
+	board[0][2] = Empty
+	board[1][2] = Empty
+	assert.True(t, Position{Board: board, WinLength: 3, ToMove: 1}.CanStillBeWon())
+
+	// the bottom row is only open to X, who needs more moves than are left to fill it
+	board = [][]int{
+		{0, 1, 0, 1},
+		{1, 0, 1, 0},
+		{0, Empty, Empty, Empty},
+	}
+	assert.False(t, Position{Board: board, WinLength: 4, ToMove: 0}.CanStillBeWon())
+	assert.True(t, Position{Board: board, WinLength: 3, ToMove: 0}.CanStillBeWon())
+}
+
+func TestCanStillBeWonThreePlayers(t *testing.T) {
+
+	// only the top row is open, mark 2 needs both of its squares but the four squares left are shared by three players
+	board := [][]int{
+		{2, 2, Empty, Empty},
+		{0, 1, 0, 1},
+		{1, Empty, 1, 0},
+		{1, 1, Empty, 0},
+	}
+	assert.False(t, Position{Board: board, WinLength: 4, ToMove: 0, TurnOrder: []int{0, 1, 2}}.CanStillBeWon())
+
+	// moving first, mark 2 gets the extra square
+	assert.True(t, Position{Board: board, WinLength: 4, ToMove: 2, TurnOrder: []int{2, 0, 1}}.CanStillBeWon())
+}
+
+func TestGravityLegalMoves(t *testing.T) {
+
+	board := newBoard(3, 4)
+	board[2][1] = 0
+	board[1][1] = 1
+	board[0][1] = 0
+
+	// one square per column sitting on the bottom or on a played square, and none in the full column
+	moves := Position{Board: board, WinLength: 3, Gravity: true}.LegalMoves()
+	assert.Equal(t, []Square{{Row: 2, Column: 0}, {Row: 2, Column: 2}, {Row: 2, Column: 3}}, moves)
+	assert.Equal(t, -1, DropRow(board, 1))
+	assert.Equal(t, 2, DropRow(board, 3))
+}
+
+func TestBestMoveGravityBlocksThree(t *testing.T) {
+
+	// Connect Four: player 1 has three along the bottom row and player 0 must drop into column 3
+	board := newBoard(6, 7)
+	board[5][0] = 1
+	board[5][1] = 1
+	board[5][2] = 1
+	board[4][0] = 0
+	board[4][1] = 0
+
+	p := Position{Board: board, WinLength: 4, ToMove: 0, Gravity: true}
+	m, err := BestMove(p, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 5, Column: 3}, m)
+}
+
+func TestBestMoveTakesTheWin(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	// X X .
+	// O O .
+	// . . .
+	board := newBoard(3, 3)
+	board[0][0], board[0][1] = 0, 0
+	board[1][0], board[1][1] = 1, 1
+
+	m, err := BestMove(Position{Board: board, WinLength: 3, ToMove: 0}, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 0, Column: 2}, m)
+
+	m, err = BestMove(Position{Board: board, WinLength: 3, ToMove: 1}, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 1, Column: 2}, m)
+
+	// the caller's board is never modified by the search
+	assert.Equal(t, Empty, board[2][2])
+}
+
+func TestBestMoveAgainstItselfIsADraw(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+	board := newBoard(3, 3)
+
+	toMove := 0
+	for !IsFull(board) {
+		m, err := BestMove(Position{Board: board, WinLength: 3, ToMove: toMove}, rng)
+		assert.NoError(t, err)
+		board[m.Row][m.Column] = toMove
+		assert.False(t, IsWinningMove(board, m.Row, m.Column, 3))
+		toMove = 1 - toMove
+	}
+}
+
+func TestEasyMoveBlocks(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+	board := newBoard(3, 3)
+	board[0][0], board[1][1] = 1, 1
+	board[0][1] = 0
+
+	m, err := EasyMove(Position{Board: board, WinLength: 3, ToMove: 0}, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 2, Column: 2}, m)
+}
+
+func TestBestMoveLargeBoardBlocksFour(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+	board := newBoard(15, 15)
+	for c := 5; c < 9; c++ {
+		board[7][c] = 1
+	}
+	board[6][6], board[8][8], board[7][4] = 0, 0, 0
+
+	m, err := BestMove(Position{Board: board, WinLength: 5, ToMove: 0}, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 7, Column: 9}, m)
+}
+
+func TestAnalyzeSolvedPosition(t *testing.T) {
+
+	// X . .
+	// . O .
+	// . . X   O to move must not play a corner
+	board := newBoard(3, 3)
+	board[0][0], board[2][2] = 0, 0
+	board[1][1] = 1
+
+	analysis, err := Analyze(Position{Board: board, WinLength: 3, ToMove: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, ResultDraw, analysis.Result)
+	assert.True(t, analysis.Decided)
+	assert.Len(t, analysis.Evaluations, 6)
+	assert.ElementsMatch(t, []Square{{0, 1}, {1, 0}, {1, 2}, {2, 1}}, analysis.BestMoves)
+
+	for _, e := range analysis.Evaluations {
+		if e.Square == (Square{Row: 0, Column: 2}) {
+			assert.Equal(t, ResultLoss, e.Result)
+			assert.Equal(t, 4, *e.Distance)
+		}
+	}
+}
+
+func TestAnalyzeLargeBoardSeesTheWin(t *testing.T) {
+
+	board := newBoard(15, 15)
+	for c := 5; c < 9; c++ {
+		board[7][c] = 0
+	}
+	board[6][6], board[8][8], board[7][4] = 1, 1, 1
+
+	analysis, err := Analyze(Position{Board: board, WinLength: 5, ToMove: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, ResultWin, analysis.Result)
+	assert.True(t, analysis.Decided)
+	assert.Equal(t, []Square{{Row: 7, Column: 9}}, analysis.BestMoves)
+	assert.Len(t, analysis.Evaluations, 15*15-7)
+}
+
+func TestSearchRefusesMoreThanTwoMarks(t *testing.T) {
+
+	// a party game's third player places mark 2, which the searches can not hand the turn to
+	board := newBoard(4, 4)
+	board[0][0], board[1][1], board[2][2] = 0, 1, 2
+	rng := rand.New(rand.NewSource(1))
+
+	_, err := Analyze(Position{Board: board, WinLength: 3, ToMove: 0})
+	assert.Error(t, err)
+
+	_, err = BestMove(Position{Board: newBoard(4, 4), WinLength: 3, ToMove: 2}, rng)
+	assert.Error(t, err)
+
+	_, err = EasyMove(Position{Board: newBoard(4, 4), WinLength: 3, ToMove: 0, TurnOrder: []int{0, 1, 2}}, rng)
+	assert.Error(t, err)
+
+	// picking any empty square needs no search
+	m := RandomMove(Position{Board: board, WinLength: 3, ToMove: 0}, rng)
+	assert.Equal(t, Empty, board[m.Row][m.Column])
+}
+
+func newBoard(rows, columns int) [][]int {
+	board := [][]int{}
+	for i := 0; i < rows; i++ {
+		row := []int{}
+		for j := 0; j < columns; j++ {
+			row = append(row, Empty)
+		}
+		board = append(board, row)
+	}
+	return board
+}
+
+func TestEvaluateStaysBelowHeuristicWin(t *testing.T) {
+
+	// every stretch of the largest board holding as many marks as a weight counts
+	assert.Less(t, 4*25*25*windowWeight(25), heuristicWin)
+}
@@ -0,0 +1,123 @@
+package engine
+
+/*
+	Numerical TicTacToe is played on a 3x3 board with the numbers 1 to 9, each placed once. The first player places the
+	odd numbers and the second the even ones, and whoever completes a line adding up to 15 wins, whichever numbers it holds
+	The board holds the numbers placed rather than players
+*/
+
+const (
+	// NumericalSide is the number of rows and columns of a numerical board
+	NumericalSide = 3
+
+	// NumericalTarget is the sum a complete line needs to win
+	NumericalTarget = 15
+
+	// NumericalHighest is the highest number there is to place, the lowest is 1
+	NumericalHighest = NumericalSide * NumericalSide
+)
+
+// NumericalNumbers returns the numbers placed by the player at seat, the odd ones for seat 0 and the even ones for seat 1
+func NumericalNumbers(seat int) []int {
+
+	numbers := []int{}
+	for n := 1 + seat; n <= NumericalHighest; n += 2 {
+		numbers = append(numbers, n)
+	}
+
+	return numbers
+}
+
+// NumericalOwner returns the seat placing number
+func NumericalOwner(number int) int {
+	return (number + 1) % 2
+}
+
+// IsNumericalWinningMove checks if the number placed at row, col completed a line adding up to NumericalTarget
+func IsNumericalWinningMove(board [][]int, row, col int) bool {
+
+	for _, line := range numericalLines() {
+		through := false
+		sum := 0
+		for _, s := range line {
+			if s.Row == row && s.Column == col {
+				through = true
+			}
+			if board[s.Row][s.Column] == Empty {
+				sum = -1
+				break
+			}
+			sum += board[s.Row][s.Column]
+		}
+
+		if through && sum == NumericalTarget {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumericalCanStillBeWon returns true while the empty squares of some line can be filled with numbers left to place
+// so that the line adds up to NumericalTarget. numbers holds what both players have left
+func NumericalCanStillBeWon(board [][]int, numbers []int) bool {
+
+	for _, line := range numericalLines() {
+		empty := 0
+		sum := 0
+		for _, s := range line {
+			if board[s.Row][s.Column] == Empty {
+				empty++
+			} else {
+				sum += board[s.Row][s.Column]
+			}
+		}
+
+		if empty > 0 && canSum(numbers, empty, NumericalTarget-sum) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canSum returns true when count of the numbers add up to target, each number being used at most once
+func canSum(numbers []int, count, target int) bool {
+
+	if count == 0 {
+		return target == 0
+	}
+
+	for i, n := range numbers {
+		if n <= target && canSum(numbers[i+1:], count-1, target-n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// numericalLines returns the rows, the columns and the two diagonals of the board
+func numericalLines() [][]Square {
+
+	lines := [][]Square{}
+	for r := 0; r < NumericalSide; r++ {
+		for c := 0; c < NumericalSide; c++ {
+			for _, d := range lineDirections {
+				endRow := r + (NumericalSide-1)*d[0]
+				endCol := c + (NumericalSide-1)*d[1]
+				if endRow >= NumericalSide || endCol < 0 || endCol >= NumericalSide {
+					continue
+				}
+
+				line := []Square{}
+				for i := 0; i < NumericalSide; i++ {
+					line = append(line, Square{Row: r + i*d[0], Column: c + i*d[1]})
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericalNumbers(t *testing.T) {
+
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, NumericalNumbers(0))
+	assert.Equal(t, []int{2, 4, 6, 8}, NumericalNumbers(1))
+	assert.Equal(t, 0, NumericalOwner(9))
+	assert.Equal(t, 1, NumericalOwner(4))
+	assert.Len(t, numericalLines(), 8)
+}
+
+func TestIsNumericalWinningMove(t *testing.T) {
+
+	// 8 + 6 + 1 is 15 along the diagonal, whoever placed them
+	board := [][]int{
+		{8, 3, Empty},
+		{Empty, 6, Empty},
+		{Empty, Empty, 1},
+	}
+	assert.True(t, IsNumericalWinningMove(board, 2, 2))
+
+	// the top row is not complete, and a line that is complete only counts for a move on it
+	assert.False(t, IsNumericalWinningMove(board, 0, 1))
+
+	board[2][2] = 2
+	assert.False(t, IsNumericalWinningMove(board, 2, 2))
+}
+
+func TestNumericalCanStillBeWon(t *testing.T) {
+
+	assert.True(t, NumericalCanStillBeWon(newBoard(3, 3), append(NumericalNumbers(0), NumericalNumbers(1)...)))
+
+	// the bottom row needs 7 from two numbers, and every other open line needs a single 5, 7 or 10
+	board := [][]int{
+		{1, 2, 3},
+		{4, 7, 5},
+		{Empty, 8, Empty},
+	}
+	assert.False(t, NumericalCanStillBeWon(board, []int{9, 6}))
+
+	// two numbers adding up to 7 would still complete the bottom row
+	assert.True(t, NumericalCanStillBeWon(board, []int{1, 6}))
+}
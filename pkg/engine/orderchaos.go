@@ -0,0 +1,74 @@
+package engine
+
+/*
+	Order and Chaos is played on a 6x6 board where both players place either mark. Order wins with exactly five in a row
+	of a single mark, a line of six is an overline and does not count. Chaos wins once no such line can be made any more
+*/
+
+// IsOrderWinningMove checks if the mark placed at row, col completed a line of exactly winLength squares
+// The move may lengthen a line past winLength in one direction and still complete one of winLength in another
+func IsOrderWinningMove(board [][]int, row, col, winLength int) bool {
+
+	mark := board[row][col]
+	if mark == Empty {
+		return false
+	}
+
+	for _, d := range lineDirections {
+		squareCount := 1
+		squareCount += countSquaresInDirection(board, row, col, d[0], d[1], mark)
+		squareCount += countSquaresInDirection(board, row, col, -d[0], -d[1], mark)
+
+		if squareCount == winLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OrderCanStillBeWon returns true while some line of winLength squares can still be filled with a single mark
+// without the squares on either end of it holding that mark too, which would make an overline
+// Either player may place either mark, so every square left counts towards every line
+func OrderCanStillBeWon(board [][]int, winLength int) bool {
+
+	for r := range board {
+		for c := range board[r] {
+			for _, d := range lineDirections {
+				endRow := r + (winLength-1)*d[0]
+				endCol := c + (winLength-1)*d[1]
+				if endRow < 0 || endRow >= len(board) || endCol < 0 || endCol >= len(board[endRow]) {
+					continue
+				}
+
+				for mark := 0; mark < 2; mark++ {
+					if canCompleteExactly(board, r, c, d, winLength, mark) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// canCompleteExactly checks if the winLength squares from r, c in direction d can all hold mark
+// while the squares just before and just after them hold something else
+func canCompleteExactly(board [][]int, r, c int, d [2]int, winLength, mark int) bool {
+
+	for i := 0; i < winLength; i++ {
+		if square := board[r+i*d[0]][c+i*d[1]]; square != Empty && square != mark {
+			return false
+		}
+	}
+
+	for _, i := range []int{-1, winLength} {
+		row, col := r+i*d[0], c+i*d[1]
+		if row >= 0 && row < len(board) && col >= 0 && col < len(board[row]) && board[row][col] == mark {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsOrderWinningMove(t *testing.T) {
+
+	board := newBoard(6, 6)
+	for col := 0; col < 5; col++ {
+		board[0][col] = 1
+	}
+	assert.True(t, IsOrderWinningMove(board, 0, 4, 5))
+
+	// the sixth mark makes an overline
+	board[0][5] = 1
+	assert.False(t, IsOrderWinningMove(board, 0, 5, 5))
+	assert.True(t, IsWinningMove(board, 0, 5, 5))
+}
+
+func TestOrderCanStillBeWon(t *testing.T) {
+
+	// only the top row can still take a single symbol, and the X filling it would make six Xs in a row
+	board := [][]int{
+		{0, 0, 0, 0, Empty, 0},
+		{1, 1, 0, 0, 1, 0},
+		{1, 1, 0, 1, 1, 0},
+		{0, 1, 0, 0, 0, 0},
+		{1, 0, 1, 0, 0, 1},
+		{1, 0, 1, 0, 0, 1},
+	}
+	assert.True(t, WildCanStillBeWon(board, 5))
+	assert.False(t, OrderCanStillBeWon(board, 5))
+
+	// with an O at the end of the row the fifth X makes exactly five
+	board[0][5] = 1
+	assert.True(t, OrderCanStillBeWon(board, 5))
+}
@@ -0,0 +1,141 @@
+package engine
+
+import "fmt"
+
+/*
+	Quantum TicTacToe is played on a 3x3 board where every move places a spooky mark in two squares at once, the mark
+	being in both of them until it is measured. The marks are numbered in the order they are played, their subscript.
+	The squares and the spooky marks joining them form the entanglement graph. A mark closing a cycle of the graph makes
+	it collapse: the opponent of the player who placed it decides which of its two squares it ends up in, and every mark
+	sharing a square with a collapsed mark is forced into its other square, until no mark tied to the cycle is left.
+	Collapsed marks are classical and fill their square for good, and three classical marks of a player in a row win.
+	When a collapse completes lines for both players at once, the line whose highest subscript is the lowest scores a
+	full point and the other line half a point
+*/
+
+// QuantumSide is the number of rows and columns of a quantum board, and the length of a winning line
+const QuantumSide = 3
+
+// SpookyMark is a mark in superposition over two squares
+type SpookyMark struct {
+	Squares [2]Square
+}
+
+// QuantumClosesCycle returns true when the last of marks closes a cycle of the entanglement graph formed by the marks
+// before it. marks must only hold the marks still in superposition, which never form a cycle among themselves
+func QuantumClosesCycle(marks []SpookyMark) bool {
+
+	if len(marks) == 0 {
+		return false
+	}
+
+	// squares joined by a mark end up with the same root
+	parent := map[Square]Square{}
+	var root func(s Square) Square
+	root = func(s Square) Square {
+		p, ok := parent[s]
+		if !ok || p == s {
+			return s
+		}
+		return root(p)
+	}
+
+	for _, mark := range marks[:len(marks)-1] {
+		parent[root(mark.Squares[0])] = root(mark.Squares[1])
+	}
+
+	last := marks[len(marks)-1]
+	return root(last.Squares[0]) == root(last.Squares[1])
+}
+
+// QuantumCollapse collapses the mark at index first of marks into square, one of its two squares, and every mark
+// forced by it. Returns the square each collapsed mark ends up in by its index into marks
+func QuantumCollapse(marks []SpookyMark, first int, square Square) (map[int]Square, error) {
+
+	if first < 0 || first >= len(marks) {
+		return nil, fmt.Errorf("there is no mark %d to collapse", first)
+	}
+
+	if marks[first].Squares[0] != square && marks[first].Squares[1] != square {
+		return nil, fmt.Errorf("the mark is in row %d column %d and row %d column %d, it can not collapse into row %d column %d",
+			marks[first].Squares[0].Row, marks[first].Squares[0].Column, marks[first].Squares[1].Row, marks[first].Squares[1].Column,
+			square.Row, square.Column)
+	}
+
+	collapsed := map[int]Square{first: square}
+	taken := []Square{square}
+	for len(taken) > 0 {
+		s := taken[0]
+		taken = taken[1:]
+
+		// every other mark in the square that was just taken is pushed into its other square
+		for i, mark := range marks {
+			if _, ok := collapsed[i]; ok {
+				continue
+			}
+
+			var other Square
+			switch s {
+			case mark.Squares[0]:
+				other = mark.Squares[1]
+			case mark.Squares[1]:
+				other = mark.Squares[0]
+			default:
+				continue
+			}
+
+			collapsed[i] = other
+			taken = append(taken, other)
+		}
+	}
+
+	return collapsed, nil
+}
+
+// QuantumScores scores the lines of classical marks on board, numbers holding the subscript of the mark in each square
+// Returns the points of every player owning a line by their mark, and nothing while no line is complete
+func QuantumScores(board, numbers [][]int) map[int]float64 {
+
+	// lowest holds the lowest highest subscript among the lines of each player
+	lowest := map[int]int{}
+	for r := range board {
+		for c := range board[r] {
+			for _, d := range lineDirections {
+				endRow := r + (QuantumSide-1)*d[0]
+				endCol := c + (QuantumSide-1)*d[1]
+				if endRow >= len(board) || endCol < 0 || endCol >= len(board[r]) {
+					continue
+				}
+
+				mark := board[r][c]
+				highest := 0
+				for i := 0; i < QuantumSide && mark != Empty; i++ {
+					if board[r+i*d[0]][c+i*d[1]] != mark {
+						mark = Empty
+					} else if numbers[r+i*d[0]][c+i*d[1]] > highest {
+						highest = numbers[r+i*d[0]][c+i*d[1]]
+					}
+				}
+				if mark == Empty {
+					continue
+				}
+
+				if l, ok := lowest[mark]; !ok || highest < l {
+					lowest[mark] = highest
+				}
+			}
+		}
+	}
+
+	scores := map[int]float64{}
+	for mark, highest := range lowest {
+		scores[mark] = 1
+		for other, h := range lowest {
+			if other != mark && h < highest {
+				scores[mark] = 0.5
+			}
+		}
+	}
+
+	return scores
+}
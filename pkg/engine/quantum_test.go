@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantumCollapse(t *testing.T) {
+
+	marks := []SpookyMark{
+		{Squares: [2]Square{{Row: 0, Column: 0}, {Row: 0, Column: 1}}},
+		{Squares: [2]Square{{Row: 0, Column: 1}, {Row: 1, Column: 1}}},
+		{Squares: [2]Square{{Row: 1, Column: 1}, {Row: 2, Column: 2}}},
+	}
+	assert.False(t, QuantumClosesCycle(marks))
+
+	// the fourth mark joins the center back to the corner
+	marks = append(marks, SpookyMark{Squares: [2]Square{{Row: 1, Column: 1}, {Row: 0, Column: 0}}})
+	assert.True(t, QuantumClosesCycle(marks))
+
+	_, err := QuantumCollapse(marks, 3, Square{Row: 2, Column: 2})
+	assert.Error(t, err)
+
+	// the cycle collapses around, and pushes the mark hanging off the center into the corner
+	collapsed, err := QuantumCollapse(marks, 3, Square{Row: 0, Column: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]Square{
+		0: {Row: 0, Column: 1},
+		1: {Row: 1, Column: 1},
+		2: {Row: 2, Column: 2},
+		3: {Row: 0, Column: 0},
+	}, collapsed)
+}
+
+func TestQuantumScores(t *testing.T) {
+
+	board := newBoard(3, 3)
+	numbers := newBoard(3, 3)
+	assert.Empty(t, QuantumScores(board, numbers))
+
+	// both players completed a line in the same collapse, the top row's highest subscript is the lowest
+	board = [][]int{
+		{0, 0, 0},
+		{Empty, Empty, Empty},
+		{1, 1, 1},
+	}
+	numbers = [][]int{
+		{1, 3, 5},
+		{Empty, Empty, Empty},
+		{2, 4, 6},
+	}
+	assert.Equal(t, map[int]float64{0: 1, 1: 0.5}, QuantumScores(board, numbers))
+}
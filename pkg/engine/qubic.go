@@ -0,0 +1,129 @@
+package engine
+
+/*
+	Qubic is TicTacToe in a 4x4x4 cube, won by 4 in a row along any of its 76 lines
+	The cube is stored as a single board with its layers stacked on top of each other, so layer l row r of the cube
+	is row l*QubicSide+r of the board
+*/
+
+// QubicSide is the number of layers, rows and columns of the cube, and the length of a winning line
+const QubicSide = 4
+
+// Point is a square of the cube
+type Point struct {
+	Layer  int `json:"layer"`
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// qubicLines holds every line of the cube, they never change so they are only worked out once
+var qubicLines = cubeLines(QubicSide)
+
+// QubicLines returns every line of the cube: rows, columns, pillars, the diagonals of every plane and the 4 space diagonals
+func QubicLines() [][]Point {
+	return qubicLines
+}
+
+// cubeLines finds every line of side squares in a cube of side squares along each edge
+// A line is walked from its first square, the one whose predecessor along the direction lies outside the cube
+func cubeLines(side int) [][]Point {
+
+	inside := func(p Point) bool {
+		return p.Layer >= 0 && p.Layer < side && p.Row >= 0 && p.Row < side && p.Column >= 0 && p.Column < side
+	}
+
+	lines := [][]Point{}
+	for _, d := range cubeDirections() {
+		for l := 0; l < side; l++ {
+			for r := 0; r < side; r++ {
+				for c := 0; c < side; c++ {
+					start := Point{Layer: l, Row: r, Column: c}
+					before := Point{Layer: l - d.Layer, Row: r - d.Row, Column: c - d.Column}
+					end := Point{Layer: l + (side-1)*d.Layer, Row: r + (side-1)*d.Row, Column: c + (side-1)*d.Column}
+					if inside(before) || !inside(end) {
+						continue
+					}
+
+					line := []Point{}
+					for i := 0; i < side; i++ {
+						line = append(line, Point{Layer: start.Layer + i*d.Layer, Row: start.Row + i*d.Row, Column: start.Column + i*d.Column})
+					}
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+
+	return lines
+}
+
+// cubeDirections returns the 13 directions a line can run in, one of each pair of opposite steps
+func cubeDirections() []Point {
+
+	directions := []Point{}
+	for l := -1; l <= 1; l++ {
+		for r := -1; r <= 1; r++ {
+			for c := -1; c <= 1; c++ {
+				// keep the step whose first non zero component is positive
+				if l > 0 || (l == 0 && r > 0) || (l == 0 && r == 0 && c > 0) {
+					directions = append(directions, Point{Layer: l, Row: r, Column: c})
+				}
+			}
+		}
+	}
+
+	return directions
+}
+
+// QubicWinner returns the mark of the player owning a complete line of the cube, or Empty
+func QubicWinner(board [][]int) int {
+
+	for _, line := range qubicLines {
+		owner := qubicSquare(board, line[0])
+		if owner == Empty {
+			continue
+		}
+
+		complete := true
+		for _, p := range line[1:] {
+			if qubicSquare(board, p) != owner {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return owner
+		}
+	}
+
+	return Empty
+}
+
+// QubicCanStillBeWon returns true while some line of the cube holds the marks of only one player, and that player
+// has enough moves left to fill the rest of it. toMove is the mark of the player whose turn it is
+func QubicCanStillBeWon(board [][]int, toMove int) bool {
+
+	movesLeft := movesLeft(board, []int{toMove, 1 - toMove})
+
+	for _, line := range qubicLines {
+		squares := []int{}
+		for _, p := range line {
+			squares = append(squares, qubicSquare(board, p))
+		}
+		if lineIsOpen(squares, movesLeft) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// QubicBoardRow returns the row of the stacked board holding row of layer
+func QubicBoardRow(layer, row int) int {
+	return layer*QubicSide + row
+}
+
+// qubicSquare reads a square of the cube from the stacked board
+func qubicSquare(board [][]int, p Point) int {
+	return board[QubicBoardRow(p.Layer, p.Row)][p.Column]
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQubicLines(t *testing.T) {
+
+	lines := QubicLines()
+	assert.Len(t, lines, 76)
+
+	// every line is 4 squares long and no line is counted twice
+	seen := map[[QubicSide]Point]bool{}
+	for _, line := range lines {
+		assert.Len(t, line, QubicSide)
+		key := [QubicSide]Point{}
+		copy(key[:], line)
+		assert.False(t, seen[key])
+		seen[key] = true
+	}
+}
+
+func TestQubicWinner(t *testing.T) {
+
+	board := newBoard(QubicSide*QubicSide, QubicSide)
+	assert.Equal(t, Empty, QubicWinner(board))
+
+	// a space diagonal from one corner of the cube to the other
+	for i := 0; i < QubicSide; i++ {
+		board[QubicBoardRow(i, i)][QubicSide-1-i] = 1
+	}
+	assert.Equal(t, 1, QubicWinner(board))
+}
+
+func TestQubicCanStillBeWon(t *testing.T) {
+
+	board := newBoard(QubicSide*QubicSide, QubicSide)
+	assert.True(t, QubicCanStillBeWon(board, 0))
+
+	// every line of this full cube holds both marks
+	board = [][]int{
+		{1, 0, 0, 0},
+		{0, 1, 1, 1},
+		{0, 0, 1, 1},
+		{0, 1, 0, 0},
+
+		{0, 1, 1, 1},
+		{1, 0, 0, 0},
+		{1, 1, 0, 1},
+		{1, 0, 1, 1},
+
+		{1, 0, 0, 1},
+		{1, 0, 1, 1},
+		{1, 1, 1, 0},
+		{0, 0, 1, 0},
+
+		{1, 0, 1, 0},
+		{0, 1, 1, 0},
+		{1, 0, 0, 0},
+		{0, 0, 0, 1},
+	}
+	assert.Equal(t, Empty, QubicWinner(board))
+	assert.False(t, QubicCanStillBeWon(board, 0))
+
+	// emptying a corner opens lines through it that hold only 0s, but only while 0 gets to fill the square
+	board[0][0] = Empty
+	assert.True(t, QubicCanStillBeWon(board, 0))
+	assert.False(t, QubicCanStillBeWon(board, 1))
+}
@@ -0,0 +1,144 @@
+package engine
+
+// RuleSet decides what completing a line does to the player completing it
+type RuleSet int
+
+const (
+	// RulesStandard wins the game for the player completing a line
+	RulesStandard RuleSet = iota
+
+	// RulesMisere loses the game for the player completing a line
+	RulesMisere
+
+	// RulesNotakto has both players place the same mark on one or more boards. Completing a line kills its board,
+	// nobody may play on a dead board any more and whoever kills the last board loses
+	RulesNotakto
+)
+
+// Outcome is what a move did to the game for the player making it
+type Outcome int
+
+const (
+	// OutcomeNone leaves the game undecided
+	OutcomeNone Outcome = iota
+
+	// OutcomeWin wins the game for the player making the move
+	OutcomeWin
+
+	// OutcomeLoss loses the game for the player making the move
+	OutcomeLoss
+)
+
+// MoveOutcome judges the move just played at row, col by the rules of the position
+func (p Position) MoveOutcome(row, col int) Outcome {
+	return p.rules().outcome(p.Board, row, col)
+}
+
+// IsLegalMove returns true if the player to move may play row, col
+func (p Position) IsLegalMove(row, col int) bool {
+	return p.rules().playable(p.Board, row, col)
+}
+
+// rules is everything the searches need to know to play a position by its rules
+type rules struct {
+	winLength int
+	gravity   bool
+	ruleSet   RuleSet
+	boards    int
+}
+
+// rules returns the rules the position is played by
+func (p Position) rules() rules {
+	return rules{winLength: p.WinLength, gravity: p.Gravity, ruleSet: p.Rules, boards: p.Boards}
+}
+
+// legalMoves returns every square that may be played on board, in row major order
+func (r rules) legalMoves(board [][]int) []Square {
+
+	moves := []Square{}
+	for row := range board {
+		for col := range board[row] {
+			if r.playable(board, row, col) {
+				moves = append(moves, Square{Row: row, Column: col})
+			}
+		}
+	}
+
+	return moves
+}
+
+// playable returns true if row, col may be played, which in notakto also needs its board to be alive
+func (r rules) playable(board [][]int, row, col int) bool {
+
+	if !isPlayable(board, row, col, r.gravity) {
+		return false
+	}
+
+	return r.ruleSet != RulesNotakto || !r.boardDead(board, row/r.boardRows(board))
+}
+
+// outcome judges the move just played at row, col
+func (r rules) outcome(board [][]int, row, col int) Outcome {
+
+	switch r.ruleSet {
+	case RulesMisere:
+		if IsWinningMove(board, row, col, r.winLength) {
+			return OutcomeLoss
+		}
+	case RulesNotakto:
+		// the move was played on a live board, so it only decides the game when it killed the last one
+		for b := 0; b < r.boardCount(); b++ {
+			if !r.boardDead(board, b) {
+				return OutcomeNone
+			}
+		}
+		return OutcomeLoss
+	default:
+		if IsWinningMove(board, row, col, r.winLength) {
+			return OutcomeWin
+		}
+	}
+
+	return OutcomeNone
+}
+
+// boardCount returns the number of boards stacked in the board, a position without any is a single board
+func (r rules) boardCount() int {
+
+	if r.boards < 1 {
+		return 1
+	}
+
+	return r.boards
+}
+
+// boardRows returns the number of rows of each of the stacked boards
+func (r rules) boardRows(board [][]int) int {
+	return len(board) / r.boardCount()
+}
+
+// boardDead returns true once a line of notakto board b is complete. Every mark counts, whoever placed it
+func (r rules) boardDead(board [][]int, b int) bool {
+
+	rows := r.boardRows(board)
+	shared := make([][]int, rows)
+	for i := range shared {
+		shared[i] = make([]int, len(board[b*rows+i]))
+		for c, square := range board[b*rows+i] {
+			shared[i][c] = Empty
+			if square != Empty {
+				shared[i][c] = 0
+			}
+		}
+	}
+
+	for i, row := range shared {
+		for c := range row {
+			if IsWinningMove(shared, i, c, r.winLength) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
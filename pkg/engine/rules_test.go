@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMisereMoveOutcome(t *testing.T) {
+
+	board := newBoard(3, 3)
+	board[0][0] = 0
+	board[1][1] = 0
+	board[2][2] = 0
+
+	assert.Equal(t, OutcomeWin, Position{Board: board, WinLength: 3}.MoveOutcome(2, 2))
+	assert.Equal(t, OutcomeLoss, Position{Board: board, WinLength: 3, Rules: RulesMisere}.MoveOutcome(2, 2))
+}
+
+func TestMisereAnalyze(t *testing.T) {
+
+	// misère TicTacToe is a draw with best play, and the only opening that does not lose is the center
+	analysis, err := Analyze(Position{Board: newBoard(3, 3), WinLength: 3, Rules: RulesMisere})
+	assert.NoError(t, err)
+	assert.True(t, analysis.Decided)
+	assert.Equal(t, ResultDraw, analysis.Result)
+	assert.Equal(t, []Square{{Row: 1, Column: 1}}, analysis.BestMoves)
+
+	// completing the diagonal would lose, so both the bot and the easy bot play the other square
+	board := [][]int{
+		{0, 1, Empty},
+		{1, 0, 1},
+		{1, 0, Empty},
+	}
+	p := Position{Board: board, WinLength: 3, ToMove: 0, Rules: RulesMisere}
+	rng := rand.New(rand.NewSource(1))
+	m, err := BestMove(p, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 0, Column: 2}, m)
+
+	m, err = EasyMove(p, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Square{Row: 0, Column: 2}, m)
+}
+
+func TestNotaktoDeadBoards(t *testing.T) {
+
+	// two 3x3 boards stacked into a 6x3 board, marks count whoever placed them
+	board := newBoard(6, 3)
+	board[0][0] = 0
+	board[0][1] = 1
+	board[0][2] = 0
+	p := Position{Board: board, WinLength: 3, Rules: RulesNotakto, Boards: 2}
+
+	// killing the first board leaves the second one to play on
+	assert.Equal(t, OutcomeNone, p.MoveOutcome(0, 2))
+	assert.False(t, p.IsLegalMove(1, 1))
+	assert.True(t, p.IsLegalMove(4, 1))
+	assert.Len(t, p.LegalMoves(), 9)
+
+	// lines do not run from one board into the next
+	board[3][0] = 1
+	board[4][0] = 1
+	assert.Equal(t, OutcomeNone, p.MoveOutcome(4, 0))
+
+	// killing the last board loses
+	board[5][0] = 0
+	assert.Equal(t, OutcomeLoss, p.MoveOutcome(5, 0))
+	assert.True(t, p.CanStillBeWon())
+}
+
+func TestNotaktoAnalyze(t *testing.T) {
+
+	// the first player wins notakto on a single 3x3 board by taking the center
+	analysis, err := Analyze(Position{Board: newBoard(3, 3), WinLength: 3, Rules: RulesNotakto, Boards: 1})
+	assert.NoError(t, err)
+	assert.True(t, analysis.Decided)
+	assert.Equal(t, ResultWin, analysis.Result)
+	assert.Equal(t, []Square{{Row: 1, Column: 1}}, analysis.BestMoves)
+}
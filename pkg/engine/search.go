@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// exactSearchLimit is the largest number of empty squares the engine will solve exactly
+	// A classic 3x3 board is always solved, larger boards fall back to the heuristic search until they fill up
+	exactSearchLimit = 10
+
+	// heuristicDepth is how many plies the heuristic search looks ahead on boards too large to solve
+	heuristicDepth = 2
+
+	// solvedWin is the score of a win on the very next move in an exact search.
+	// A result that is reached n plies later scores n less, so the distance to the result is solvedWin - |score|
+	solvedWin = 1000
+
+	// heuristicWin is far larger than any score evaluate can return, so a found win always beats a good looking position
+	// Twice its value still fits in a 32-bit int
+	heuristicWin = 1 << 29
+)
+
+// checkTwoMarks returns an error unless only marks 0 and 1 play the position, the searches hand the turn to 1 - toMove
+func (p Position) checkTwoMarks() error {
+
+	if p.ToMove != 0 && p.ToMove != 1 {
+		return fmt.Errorf("the engine only searches games of two players, mark %d is to move", p.ToMove)
+	}
+
+	if len(p.TurnOrder) > 2 {
+		return fmt.Errorf("the engine only searches games of two players, %d marks take turns", len(p.TurnOrder))
+	}
+
+	for _, row := range p.Board {
+		for _, square := range row {
+			if square != Empty && square != 0 && square != 1 {
+				return fmt.Errorf("the engine only searches games of two players, the board holds mark %d", square)
+			}
+		}
+	}
+
+	return nil
+}
+
+// scoredSquare is a legal move along with its score for the player making it
+type scoredSquare struct {
+	square Square
+	score  int
+}
+
+// scoreMoves scores every legal move in the position for the player to move
+// exact is true when the scores come from solving the position rather than from the heuristic search
+func scoreMoves(p Position) (scored []scoredSquare, exact bool) {
+
+	// the size of the search depends on the squares left, not on how many of them can be played right now
+	if emptySquares(p.Board) <= exactSearchLimit {
+		s := solver{rules: p.rules(), board: copyBoard(p.Board), memo: map[string]int{}}
+		for _, m := range p.LegalMoves() {
+			scored = append(scored, scoredSquare{square: m, score: s.scoreMove(m.Row, m.Column, p.ToMove)})
+		}
+		return scored, true
+	}
+
+	h := heuristicSearch{rules: p.rules(), board: copyBoard(p.Board)}
+	for _, m := range h.candidates() {
+		score := h.scoreMove(m.Row, m.Column, p.ToMove, heuristicDepth, -heuristicWin*2, heuristicWin*2)
+		scored = append(scored, scoredSquare{square: m, score: score})
+	}
+	return scored, false
+}
+
+// solver is an exhaustive minimax search that remembers every position it has already solved
+type solver struct {
+	rules
+	board [][]int
+	memo  map[string]int
+}
+
+// solve returns the score of the position for the player to move with perfect play from both sides
+func (s *solver) solve(toMove int) int {
+
+	key := s.key(toMove)
+	if score, ok := s.memo[key]; ok {
+		return score
+	}
+
+	best := -solvedWin
+	for r, row := range s.board {
+		for c := range row {
+			if !s.playable(s.board, r, c) {
+				continue
+			}
+			score := s.scoreMove(r, c, toMove)
+			if score > best {
+				best = score
+			}
+		}
+		// nothing beats winning right now
+		if best == solvedWin-1 {
+			break
+		}
+	}
+
+	s.memo[key] = best
+	return best
+}
+
+// scoreMove plays toMove at r, c, scores the result for toMove and takes the move back
+func (s *solver) scoreMove(r, c, toMove int) int {
+
+	s.board[r][c] = toMove
+	defer func() { s.board[r][c] = Empty }()
+
+	switch s.outcome(s.board, r, c) {
+	case OutcomeWin:
+		return solvedWin - 1
+	case OutcomeLoss:
+		return -(solvedWin - 1)
+	}
+
+	if IsFull(s.board) {
+		return 0
+	}
+
+	return backUp(s.solve(1 - toMove))
+}
+
+// key identifies a board and the player to move within the memo
+func (s *solver) key(toMove int) string {
+
+	var sb strings.Builder
+	sb.WriteByte(byte('a' + toMove))
+	for _, row := range s.board {
+		for _, square := range row {
+			sb.WriteByte(byte('b' + square))
+		}
+	}
+
+	return sb.String()
+}
+
+// backUp turns the opponent's score after a move into the mover's score, one ply further from the result
+func backUp(opponentScore int) int {
+
+	if opponentScore > 0 {
+		return -opponentScore + 1
+	}
+
+	if opponentScore < 0 {
+		return -opponentScore - 1
+	}
+
+	return 0
+}
+
+// heuristicSearch is a depth limited alpha-beta search for boards too large to solve
+// Only squares next to stones already on the board are considered, and leaves are scored by evaluate
+type heuristicSearch struct {
+	rules
+	board [][]int
+}
+
+// negamax returns the score of the position for toMove, searching depth plies ahead
+func (h *heuristicSearch) negamax(toMove, depth, alpha, beta int) int {
+
+	if depth == 0 {
+		return h.evaluate(toMove)
+	}
+
+	moves := h.candidates()
+	if len(moves) == 0 {
+		return 0
+	}
+
+	for _, m := range moves {
+		score := h.scoreMove(m.Row, m.Column, toMove, depth, alpha, beta)
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return alpha
+}
+
+// scoreMove plays toMove at r, c, scores the result for toMove with depth plies of search and takes the move back
+func (h *heuristicSearch) scoreMove(r, c, toMove, depth, alpha, beta int) int {
+
+	h.board[r][c] = toMove
+	defer func() { h.board[r][c] = Empty }()
+
+	// prefer the quickest win and the slowest loss
+	switch h.outcome(h.board, r, c) {
+	case OutcomeWin:
+		return heuristicWin + depth
+	case OutcomeLoss:
+		return -(heuristicWin + depth)
+	}
+
+	if IsFull(h.board) {
+		return 0
+	}
+
+	return -h.negamax(1-toMove, depth-1, -beta, -alpha)
+}
+
+// candidates returns the playable squares touching a played square, or the center of an empty board
+// With gravity there is at most one playable square per column, and notakto boards are small and die quickly,
+// so all of their legal moves are candidates. So are they once every square touching a played one is ruled out
+func (h *heuristicSearch) candidates() []Square {
+
+	if h.gravity || h.ruleSet == RulesNotakto {
+		return h.legalMoves(h.board)
+	}
+
+	moves := []Square{}
+	played := false
+	for r, row := range h.board {
+		for c, square := range row {
+			if square != Empty {
+				played = true
+				continue
+			}
+			if h.hasNeighbour(r, c) && h.playable(h.board, r, c) {
+				moves = append(moves, Square{Row: r, Column: c})
+			}
+		}
+	}
+
+	if !played {
+		return []Square{{Row: len(h.board) / 2, Column: len(h.board[0]) / 2}}
+	}
+
+	if len(moves) == 0 {
+		return h.legalMoves(h.board)
+	}
+
+	return moves
+}
+
+// hasNeighbour returns true if any of the eight squares around r, c has been played
+func (h *heuristicSearch) hasNeighbour(r, c int) bool {
+
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			nr, nc := r+dr, c+dc
+			if (dr == 0 && dc == 0) || nr < 0 || nr >= len(h.board) || nc < 0 || nc >= len(h.board[nr]) {
+				continue
+			}
+			if h.board[nr][nc] != Empty {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// evaluate scores the board for toMove by looking at every stretch of winLength squares
+// A stretch only holding one player's marks is still winnable by that player and is worth more the fuller it is.
+// In misère such a stretch is a liability instead, and in notakto every mark is shared so there is nothing to go on
+func (h *heuristicSearch) evaluate(toMove int) int {
+
+	if h.ruleSet == RulesNotakto {
+		return 0
+	}
+
+	score := 0
+	rows := len(h.board)
+	cols := len(h.board[0])
+	directions := [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			for _, d := range directions {
+				endRow := r + d[0]*(h.winLength-1)
+				endCol := c + d[1]*(h.winLength-1)
+				if endRow < 0 || endRow >= rows || endCol < 0 || endCol >= cols {
+					continue
+				}
+
+				counts := [2]int{}
+				for i := 0; i < h.winLength; i++ {
+					if mark := h.board[r+d[0]*i][c+d[1]*i]; mark != Empty {
+						counts[mark]++
+					}
+				}
+
+				if counts[0] > 0 && counts[1] > 0 {
+					// blocked, nobody can win here
+					continue
+				}
+				if h.ruleSet == RulesMisere {
+					score += windowWeight(counts[1-toMove]) - windowWeight(counts[toMove])
+				} else {
+					score += windowWeight(counts[toMove]) - windowWeight(counts[1-toMove])
+				}
+			}
+		}
+	}
+
+	return score
+}
+
+// windowWeight grows steeply with the number of marks in a stretch so that threats outweigh many loose stones
+func windowWeight(count int) int {
+
+	if count == 0 {
+		return 0
+	}
+
+	// cap the weight so adding up every stretch on the largest board, 4 directions of 25x25, can never reach heuristicWin
+	if count > 5 {
+		count = 5
+	}
+
+	return 1 << (3 * count)
+}
@@ -0,0 +1,120 @@
+package engine
+
+/*
+	Ultimate TicTacToe is played on a 3x3 grid of 3x3 boards. Every board is kept on its own, next to a 3x3 meta board
+	holding what became of each of them. Boards and the cells within a board are both numbered 0 to 8 in row major order,
+	so board 4 is the center board and cell 0 its top left square.
+	The cell a player picks sends the opponent to the board with the same number. When that board is already won or
+	full the opponent may play on any board still open. Winning a board claims its cell of the meta board, and three
+	claimed cells in a row win the game
+*/
+
+const (
+	// UltimateSide is the number of boards along each side of the meta board, and of squares along each side of a board
+	UltimateSide = 3
+
+	// UltimateDrawn marks a cell of the meta board whose board filled up without a winner
+	UltimateDrawn = 2
+
+	// UltimateAnyBoard is the active board when the player to move may pick any open board
+	UltimateAnyBoard = -1
+)
+
+// NewUltimateBoards returns the empty boards of a new game, numbered in row major order
+func NewUltimateBoards() [][][]int {
+
+	boards := [][][]int{}
+	for b := 0; b < UltimateSide*UltimateSide; b++ {
+		boards = append(boards, emptyUltimateBoard())
+	}
+
+	return boards
+}
+
+// NewUltimateMetaBoard returns the meta board of a new game, every board still open
+func NewUltimateMetaBoard() [][]int {
+	return emptyUltimateBoard()
+}
+
+// emptyUltimateBoard returns a 3x3 board of Empty squares
+func emptyUltimateBoard() [][]int {
+
+	board := make([][]int, UltimateSide)
+	for r := range board {
+		board[r] = []int{Empty, Empty, Empty}
+	}
+
+	return board
+}
+
+// UltimateCell returns the value of cell in a 3x3 board, which works for a single board and for the meta board alike
+func UltimateCell(board [][]int, cell int) int {
+	return board[cell/UltimateSide][cell%UltimateSide]
+}
+
+// UltimateBoardResult decides a single board. Returns Empty while it is open, the mark of the player who won it,
+// or UltimateDrawn once it filled up without a winner
+func UltimateBoardResult(board [][]int) int {
+
+	for r := range board {
+		for c := range board[r] {
+			if IsWinningMove(board, r, c, UltimateSide) {
+				return board[r][c]
+			}
+		}
+	}
+
+	if IsFull(board) {
+		return UltimateDrawn
+	}
+
+	return Empty
+}
+
+// UltimateNextBoard returns the board the opponent has to play on after cell was played,
+// or UltimateAnyBoard when the board with that number is already decided
+func UltimateNextBoard(meta [][]int, cell int) int {
+
+	if UltimateCell(meta, cell) != Empty {
+		return UltimateAnyBoard
+	}
+
+	return cell
+}
+
+// UltimateResult decides the game from the meta board. over is true once a player claimed three cells in a row,
+// or when no line of the meta board can be claimed by anybody any more. winner is Empty for a draw
+func UltimateResult(meta [][]int) (winner int, over bool) {
+
+	openLine := false
+	for r := 0; r < UltimateSide; r++ {
+		for c := 0; c < UltimateSide; c++ {
+			for _, d := range lineDirections {
+				endRow := r + (UltimateSide-1)*d[0]
+				endCol := c + (UltimateSide-1)*d[1]
+				if endRow >= UltimateSide || endCol < 0 || endCol >= UltimateSide {
+					continue
+				}
+
+				// count what the line holds, index UltimateDrawn counts drawn boards
+				counts := [UltimateDrawn + 1]int{}
+				for i := 0; i < UltimateSide; i++ {
+					if mark := meta[r+i*d[0]][c+i*d[1]]; mark != Empty {
+						counts[mark]++
+					}
+				}
+
+				for mark := 0; mark < UltimateDrawn; mark++ {
+					if counts[mark] == UltimateSide {
+						return mark, true
+					}
+				}
+				if counts[UltimateDrawn] == 0 && (counts[0] == 0 || counts[1] == 0) {
+					openLine = true
+				}
+			}
+		}
+	}
+
+	return Empty, !openLine
+}
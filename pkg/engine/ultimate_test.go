@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUltimateNextBoard(t *testing.T) {
+
+	meta := NewUltimateMetaBoard()
+	assert.Equal(t, 2, UltimateNextBoard(meta, 2))
+
+	// once board 2 is won the opponent may play on any open board
+	board := NewUltimateBoards()[2]
+	for _, cell := range []int{0, 1, 2} {
+		board[0][cell] = 1
+	}
+	meta[0][2] = UltimateBoardResult(board)
+	assert.Equal(t, 1, UltimateCell(meta, 2))
+	assert.Equal(t, UltimateAnyBoard, UltimateNextBoard(meta, 2))
+}
+
+func TestUltimateBoardResult(t *testing.T) {
+
+	board := [][]int{
+		{0, 1, 0},
+		{0, 1, 1},
+		{1, 0, Empty},
+	}
+	assert.Equal(t, Empty, UltimateBoardResult(board))
+
+	board[2][2] = 0
+	assert.Equal(t, UltimateDrawn, UltimateBoardResult(board))
+}
+
+func TestUltimateResult(t *testing.T) {
+
+	meta := NewUltimateMetaBoard()
+	meta[0][0] = 1
+	meta[1][1] = 1
+	_, over := UltimateResult(meta)
+	assert.False(t, over)
+
+	meta[2][2] = 1
+	winner, over := UltimateResult(meta)
+	assert.True(t, over)
+	assert.Equal(t, 1, winner)
+
+	// drawn boards block every line
+	meta = [][]int{
+		{0, 1, UltimateDrawn},
+		{1, UltimateDrawn, 0},
+		{UltimateDrawn, 0, Empty},
+	}
+	winner, over = UltimateResult(meta)
+	assert.True(t, over)
+	assert.Equal(t, Empty, winner)
+}
@@ -0,0 +1,14 @@
+package engine
+
+/*
+	Wild TicTacToe lets either player place either mark on their turn, and whoever completes a line of a single mark wins
+	The board holds marks rather than players, so the engine only needs to know that every square left can take either
+*/
+
+// WildCanStillBeWon returns true while some line of winLength squares holds only one of the marks
+// Either player may place either mark, so every square left counts towards every line
+func WildCanStillBeWon(board [][]int, winLength int) bool {
+
+	empties := emptySquares(board)
+	return Position{Board: board, WinLength: winLength}.hasOpenLine(map[int]int{0: empties, 1: empties})
+}
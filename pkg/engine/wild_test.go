@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildCanStillBeWon(t *testing.T) {
+
+	// the bottom row only holds 0s, but 0 is too short of moves to fill it when only its owner may play that mark
+	board := [][]int{
+		{0, 1, 0, 1},
+		{1, 0, 1, 0},
+		{0, Empty, Empty, Empty},
+	}
+	assert.False(t, Position{Board: board, WinLength: 4, ToMove: 1}.CanStillBeWon())
+
+	// in wild both players may place 0s there
+	assert.True(t, WildCanStillBeWon(board, 4))
+
+	// once a 1 blocks the row every line holds both marks
+	board[2][2] = 1
+	assert.False(t, WildCanStillBeWon(board, 4))
+}
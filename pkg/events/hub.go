@@ -0,0 +1,133 @@
+package events
+
+import (
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+)
+
+/*
+	The events package is an in-process pub/sub hub for game updates
+	Whoever writes a game publishes what changed, and every live connection watching that game receives it
+	Nothing is stored here, a client that connects late catches up by reading the game from the DB
+*/
+
+type EventType string
+
+const (
+	// EventTypeMove is published for every move appended to a game
+	EventTypeMove EventType = "MOVE"
+
+	// EventTypeState is published when a game changes state, e.g. IN_PROGRESS -> COMPLETE
+	EventTypeState EventType = "STATE"
+
+	// EventTypeTakeback is published when moves were taken back. Every move from MoveNumber on is gone
+	EventTypeTakeback EventType = "TAKEBACK"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+const subscriberBuffer = 64
+
+// Event describes a single change to a game
+type Event struct {
+	Type   EventType `json:"type"`
+	GameID string    `json:"gameId"`
+
+	// MoveNumber and Move are set for MOVE events. MoveNumber is 0 offset, like the moves endpoints
+	// TAKEBACK events only carry the MoveNumber
+	MoveNumber *int           `json:"moveNumber,omitempty"`
+	Move       *database.Move `json:"move,omitempty"`
+
+	// State and Winner are set for STATE events. Winner is nil for a draw or a game without a winner yet
+	State  database.State `json:"state,omitempty"`
+	Winner *string        `json:"winner,omitempty"`
+
+	// Version is the version the update brought the game to, it only ever goes up. Every event of an update shares it
+	Version int `json:"version"`
+}
+
+// Subscription receives the events of a single game until it is closed
+type Subscription struct {
+	// Events is closed when the subscription is closed, or when the subscriber fell too far behind
+	Events <-chan Event
+
+	events chan Event
+	gameID string
+	hub    *Hub
+}
+
+// Hub fans out the events of every game to the subscriptions watching it
+type Hub struct {
+
+	// a channel for locking the subscribers map
+	channelLock chan bool
+
+	subscribers map[string]map[*Subscription]bool
+}
+
+// NewHub returns an empty Hub
+func NewHub() *Hub {
+
+	c := make(chan bool, 1)
+	c <- true
+	return &Hub{
+		channelLock: c,
+		subscribers: map[string]map[*Subscription]bool{},
+	}
+}
+
+// Subscribe starts receiving the events of a game
+func (h *Hub) Subscribe(gameID string) *Subscription {
+
+	<-h.channelLock
+	defer func() { h.channelLock <- true }()
+
+	events := make(chan Event, subscriberBuffer)
+	sub := &Subscription{Events: events, events: events, gameID: gameID, hub: h}
+
+	if _, ok := h.subscribers[gameID]; !ok {
+		h.subscribers[gameID] = map[*Subscription]bool{}
+	}
+	h.subscribers[gameID][sub] = true
+
+	return sub
+}
+
+// Close stops the subscription and closes its Events channel. Closing twice is harmless
+func (s *Subscription) Close() {
+
+	<-s.hub.channelLock
+	defer func() { s.hub.channelLock <- true }()
+
+	s.hub.remove(s)
+}
+
+// Publish hands the event to every subscription of its game without ever blocking the publisher
+// A subscriber whose buffer is full is dropped, it has to reconnect and catch up from the DB
+func (h *Hub) Publish(event Event) {
+
+	<-h.channelLock
+	defer func() { h.channelLock <- true }()
+
+	for sub := range h.subscribers[event.GameID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// remove forgets the subscription and closes its channel. The caller must hold the channelLock
+func (h *Hub) remove(sub *Subscription) {
+
+	subs, ok := h.subscribers[sub.gameID]
+	if !ok || !subs[sub] {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.events)
+
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.gameID)
+	}
+}
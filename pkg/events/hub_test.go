@@ -0,0 +1,43 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/seanmdeleon/TicTacToe-FlyHomes/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubDeliversToGameSubscribers(t *testing.T) {
+
+	hub := NewHub()
+	watcher := hub.Subscribe("gameID1")
+	other := hub.Subscribe("gameID2")
+
+	hub.Publish(Event{Type: EventTypeState, GameID: "gameID1", State: database.StateComplete})
+
+	event := <-watcher.Events
+	assert.Equal(t, database.StateComplete, event.State)
+	assert.Len(t, other.Events, 0)
+
+	watcher.Close()
+	watcher.Close()
+	_, ok := <-watcher.Events
+	assert.False(t, ok)
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+
+	hub := NewHub()
+	slow := hub.Subscribe("gameID1")
+
+	// publishing never blocks, the subscriber that stopped reading is dropped instead
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(Event{Type: EventTypeMove, GameID: "gameID1"})
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}